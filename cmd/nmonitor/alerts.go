@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/alert"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// newAlertManager creates the alert manager with the configured webhooks and
+// hooks and starts their delivery goroutines. Failed deliveries and hook
+// runs are passed to onError. Commands can add notifiers before calling
+// startAlerts.
+func newAlertManager(ctx context.Context, cfg *config.Config, onError func(error)) *alert.Manager {
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	m := alert.NewManager(alert.NewDetector(cfg.Alerts))
+	for _, wc := range cfg.Alerts.Webhooks {
+		wh := alert.NewWebhook(wc, onError)
+		go wh.Run(ctx)
+		m.Add(wh)
+	}
+
+	if len(cfg.Alerts.Hooks) > 0 {
+		x := alert.NewExec(cfg.Alerts.Hooks, cfg.Alerts.HookConcurrency, func(res alert.HookResult) {
+			if res.Err == nil {
+				return
+			}
+			err := fmt.Errorf("hook %q for %s %s: %w", res.Command, res.Event.Key(), res.Event.Status, res.Err)
+			if out := strings.TrimSpace(res.Output); out != "" {
+				err = fmt.Errorf("%w\n%s", err, out)
+			}
+			onError(err)
+		})
+		go x.Run(ctx)
+		m.Add(x)
+	}
+	return m
+}
+
+// startAlerts feeds poller updates to the alert manager.
+func startAlerts(ctx context.Context, m *alert.Manager, poller *monitor.Poller) {
+	sub := poller.Subscribe()
+	go func() {
+		defer sub.Close()
+		m.Run(ctx, sub)
+	}()
+}
+
+// logError reports a background error on stderr.
+func logError(err error) {
+	fmt.Fprintf(os.Stderr, "nmonitor: %v\n", err)
+}
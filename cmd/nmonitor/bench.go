@@ -0,0 +1,326 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/export"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// benchResult is the throughput of one consumer, or the total of all.
+type benchResult struct {
+	Stream        string  `json:"stream,omitempty"`
+	Consumer      string  `json:"consumer,omitempty"`
+	Delivered     uint64  `json:"delivered"`
+	Acked         uint64  `json:"acked"`
+	DeliveredRate float64 `json:"delivered_rate"`
+	AckedRate     float64 `json:"acked_rate"`
+
+	DeliveredStats *export.Distribution `json:"delivered_stats,omitempty"` // Only for consumers
+	AckedStats     *export.Distribution `json:"acked_stats,omitempty"`
+	VsBaseline     *benchChange         `json:"vs_baseline,omitempty"`
+}
+
+// benchChange compares a result with the baseline.
+type benchChange struct {
+	DeliveredRate    float64 `json:"delivered_rate"` // Rates of the baseline
+	AckedRate        float64 `json:"acked_rate"`
+	DeliveredPercent float64 `json:"delivered_change_percent"`
+	AckedPercent     float64 `json:"acked_change_percent"`
+	Regressed        bool    `json:"regressed"`
+}
+
+// benchReport is the output of a benchmark.
+type benchReport struct {
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	Duration  float64       `json:"duration_seconds"`
+	Consumers []benchResult `json:"consumers"`
+	Total     benchResult   `json:"total"`
+	Baseline  string        `json:"baseline,omitempty"`
+	Threshold float64       `json:"regression_threshold,omitempty"` // Percent
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+func runBench(args []string) int {
+	var opts options
+	fs := newFlagSet("bench", "Measure consumer throughput for a fixed duration without a UI and print the\n"+
+		"rate of every consumer and the total. Exits with 1 if a consumer could not\n"+
+		"be measured, the run was interrupted or a rate regressed against --baseline.", &opts)
+	duration := fs.Duration("duration", 0, "measurement length (default: throughput.duration of the config, or 60s)")
+	window := fs.String("window", "", "only measure the consumers of the window with this `name` or number")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	save := fs.String("save", "", "save the result as the baseline `name`")
+	against := fs.String("baseline", "", "compare the result with the baseline `name`")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(1, err)
+	}
+	if *duration <= 0 {
+		*duration = cfg.Throughput.Duration.Duration()
+	}
+
+	consumers := cfg.Consumers
+	if *window != "" {
+		idx, err := cfg.WindowIndex(*window)
+		if err != nil {
+			return fail(1, err)
+		}
+		consumers = cfg.Windows[idx].Consumers
+	}
+
+	var store *baseline.Store
+	var base *baseline.Baseline
+	if *save != "" || *against != "" {
+		if store, err = baseline.Open(cfg.Throughput.BaselineFile); err != nil {
+			return fail(1, err)
+		}
+	}
+	if *against != "" {
+		var ok bool
+		if base, ok = store.Get(*against); !ok {
+			return fail(1, fmt.Errorf("no baseline named %q in %s", *against, store.Path()))
+		}
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return fail(1, err)
+	}
+	defer nc.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	poller, err := monitor.NewPoller(nc, consumers, monitor.ScheduleFromConfig(cfg), jsOptions)
+	if err != nil {
+		return fail(1, err)
+	}
+
+	// Bracket the run with two polls. The polls in between only sample the
+	// rate distribution; the totals come from the first and the last. The
+	// measurement is open-ended and stopped after the last poll, so no poll
+	// in between can end it early.
+	tracker := monitor.NewThroughputTracker()
+	start := poller.Once()
+	tracker.Start(start.States)
+	fmt.Fprintf(os.Stderr, "nmonitor: measuring %d consumers for %s\n", len(tracker.All()), *duration)
+
+	interrupted := false
+	ticker := time.NewTicker(cfg.Poll.Interval.Duration())
+	defer ticker.Stop()
+	done := time.After(*duration)
+sampling:
+	for {
+		select {
+		case <-ctx.Done():
+			interrupted = true
+			break sampling
+		case <-done:
+			break sampling
+		case <-ticker.C:
+			tracker.Update(poller.Once().States)
+		}
+	}
+	end := poller.Once()
+	tracker.Update(end.States)
+	tracker.Stop()
+
+	measurements := tracker.All()
+	report := newBenchReport(consumers, measurements, start.States, end.States)
+	if interrupted {
+		report.Errors = append(report.Errors, fmt.Sprintf("interrupted after %.3fs", report.Duration))
+	}
+	var regressions []string
+	if base != nil {
+		regressions = report.compare(base, consumers, measurements, cfg.Throughput.RegressionThreshold)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printBenchReport(report)
+	}
+
+	for _, msg := range report.Errors {
+		fmt.Fprintf(os.Stderr, "nmonitor: %s\n", msg)
+	}
+	for _, msg := range regressions {
+		fmt.Fprintf(os.Stderr, "nmonitor: regression: %s\n", msg)
+	}
+
+	if *save != "" {
+		if len(report.Errors) > 0 {
+			fmt.Fprintf(os.Stderr, "nmonitor: not saving baseline %q of a failed run\n", *save)
+		} else if _, err := store.Save(*save, measurements); err != nil {
+			return fail(1, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "nmonitor: saved baseline %q to %s\n", *save, store.Path())
+		}
+	}
+
+	if len(report.Errors) > 0 || len(regressions) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// compare adds the change against the baseline to every result and returns
+// a description of each regression.
+func (r *benchReport) compare(base *baseline.Baseline, refs []config.ConsumerRef, measurements map[string]monitor.ThroughputMeasurement, threshold float64) []string {
+	r.Baseline = base.Name
+	r.Threshold = threshold
+	wc := base.CompareWindow(config.WindowConfig{Consumers: refs}, measurements, threshold)
+
+	byKey := make(map[string]baseline.Comparison, len(wc.Consumers))
+	for _, c := range wc.Consumers {
+		byKey[c.Ref.Key()] = c
+	}
+
+	var regressions []string
+	check := func(label string, res *benchResult, c baseline.Comparison) {
+		res.VsBaseline = newBenchChange(c)
+		for _, ch := range []struct {
+			name   string
+			change baseline.Change
+		}{{"delivered", c.Delivered}, {"acked", c.Acked}} {
+			if ch.change.Regressed {
+				regressions = append(regressions, fmt.Sprintf("%s %s rate %.1f/s is %.1f%% below baseline %q (%.1f/s)",
+					label, ch.name, ch.change.Current, -ch.change.Percent, base.Name, ch.change.Baseline))
+			}
+		}
+	}
+	for i := range r.Consumers {
+		res := &r.Consumers[i]
+		key := config.ConsumerRef{Stream: res.Stream, Consumer: res.Consumer}.Key()
+		if c, ok := byKey[key]; ok {
+			check(key, res, c)
+		}
+	}
+	if len(wc.Consumers) > 0 {
+		check("total", &r.Total, wc.Total)
+	}
+	return regressions
+}
+
+func newBenchChange(c baseline.Comparison) *benchChange {
+	return &benchChange{
+		DeliveredRate:    c.Delivered.Baseline,
+		AckedRate:        c.Acked.Baseline,
+		DeliveredPercent: c.Delivered.Percent,
+		AckedPercent:     c.Acked.Percent,
+		Regressed:        c.Regressed(),
+	}
+}
+
+// newBenchReport builds the report of the measured consumers in config
+// order. Consumers that failed at the start or the end of the run are
+// reported as errors instead.
+func newBenchReport(refs []config.ConsumerRef, measurements map[string]monitor.ThroughputMeasurement, start, end []monitor.ConsumerState) benchReport {
+	report := benchReport{Consumers: []benchResult{}}
+
+	failed := make(map[string]error)
+	for _, state := range slices.Concat(start, end) {
+		if state.Error != nil {
+			failed[state.Ref.Key()] = state.Error
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		key := ref.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if err, ok := failed[key]; ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		m, ok := measurements[key]
+		if !ok {
+			continue
+		}
+
+		if report.StartTime.IsZero() {
+			report.StartTime, report.EndTime = m.StartTime, m.EndTime
+			report.Duration = m.Duration().Seconds()
+		}
+		res := benchResult{
+			Stream:        ref.Stream,
+			Consumer:      ref.Consumer,
+			Delivered:     m.DeliveredCount(),
+			Acked:         m.AckedCount(),
+			DeliveredRate: m.DeliveredRate(),
+			AckedRate:     m.AckedRate(),
+
+			DeliveredStats: export.NewDistribution(m.DeliveredStats()),
+			AckedStats:     export.NewDistribution(m.AckedStats()),
+		}
+		report.Consumers = append(report.Consumers, res)
+
+		report.Total.Delivered += res.Delivered
+		report.Total.Acked += res.Acked
+		report.Total.DeliveredRate += res.DeliveredRate
+		report.Total.AckedRate += res.AckedRate
+	}
+	return report
+}
+
+func printBenchReport(report benchReport) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "STREAM\tCONSUMER\tDELIVERED\tACKED\tDELIVERED/S\tACKED/S\tACKED/S P50\tP95\tMAX\tSTDDEV\tLONGEST STALL"
+	if report.Baseline != "" {
+		header += "\tVS " + report.Baseline
+	}
+	fmt.Fprintln(tw, header)
+	row := func(stream, consumer string, r benchResult) {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%.1f\t%.1f", stream, consumer, r.Delivered, r.Acked, r.DeliveredRate, r.AckedRate)
+		if d := r.AckedStats; d != nil {
+			fmt.Fprintf(tw, "\t%.1f\t%.1f\t%.1f\t%.1f\t%s", d.P50, d.P95, d.Max, d.StdDev,
+				time.Duration(d.LongestGap*float64(time.Second)).Round(time.Second))
+		} else {
+			fmt.Fprint(tw, "\t\t\t\t\t")
+		}
+		if report.Baseline != "" {
+			fmt.Fprintf(tw, "\t%s", formatBenchChange(r.VsBaseline))
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, r := range report.Consumers {
+		row(r.Stream, r.Consumer, r)
+	}
+	row("TOTAL", "", report.Total)
+	tw.Flush()
+
+	fmt.Printf("\nMeasured %d consumers for %.3fs\n", len(report.Consumers), report.Duration)
+	if report.Baseline != "" {
+		fmt.Printf("Compared with baseline %q; a drop over %g%% is a regression\n", report.Baseline, report.Threshold)
+	}
+}
+
+// formatBenchChange describes the change of the delivered and acked rates.
+func formatBenchChange(c *benchChange) string {
+	if c == nil {
+		return "not in baseline"
+	}
+	text := fmt.Sprintf("delivered %+.1f%%, acked %+.1f%%", c.DeliveredPercent, c.AckedPercent)
+	if c.Regressed {
+		text += "  REGRESSED"
+	}
+	return text
+}
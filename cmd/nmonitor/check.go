@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/health"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// thresholdFlags overrides the configured check thresholds from flags.
+type thresholdFlags struct {
+	th config.Thresholds
+}
+
+func (f *thresholdFlags) register(fs *flag.FlagSet) {
+	fs.Uint64Var(&f.th.Pending.Warning, "pending-warn", 0, "warn when unprocessed messages reach `n`")
+	fs.Uint64Var(&f.th.Pending.Critical, "pending-crit", 0, "critical when unprocessed messages reach `n`")
+	fs.Uint64Var(&f.th.AckPending.Warning, "ack-pending-warn", 0, "warn when outstanding acks reach `n`")
+	fs.Uint64Var(&f.th.AckPending.Critical, "ack-pending-crit", 0, "critical when outstanding acks reach `n`")
+	fs.Uint64Var(&f.th.Redelivered.Warning, "redelivered-warn", 0, "warn when redelivered messages reach `n`")
+	fs.Uint64Var(&f.th.Redelivered.Critical, "redelivered-crit", 0, "critical when redelivered messages reach `n`")
+	fs.Var((*durationFlag)(&f.th.LastAckAge.Warning), "last-ack-warn", "warn when the last ack is older than `duration` while messages are outstanding")
+	fs.Var((*durationFlag)(&f.th.LastAckAge.Critical), "last-ack-crit", "critical when the last ack is older than `duration` while messages are outstanding")
+}
+
+// apply replaces every threshold given on the command line.
+func (f *thresholdFlags) apply(fs *flag.FlagSet, th config.Thresholds) config.Thresholds {
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "pending-warn":
+			th.Pending.Warning = f.th.Pending.Warning
+		case "pending-crit":
+			th.Pending.Critical = f.th.Pending.Critical
+		case "ack-pending-warn":
+			th.AckPending.Warning = f.th.AckPending.Warning
+		case "ack-pending-crit":
+			th.AckPending.Critical = f.th.AckPending.Critical
+		case "redelivered-warn":
+			th.Redelivered.Warning = f.th.Redelivered.Warning
+		case "redelivered-crit":
+			th.Redelivered.Critical = f.th.Redelivered.Critical
+		case "last-ack-warn":
+			th.LastAckAge.Warning = f.th.LastAckAge.Warning
+		case "last-ack-crit":
+			th.LastAckAge.Critical = f.th.LastAckAge.Critical
+		}
+	})
+	return th
+}
+
+// durationFlag parses a config.Duration from the command line.
+type durationFlag config.Duration
+
+func (d *durationFlag) String() string {
+	return time.Duration(*d).String()
+}
+
+func (d *durationFlag) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = durationFlag(v)
+	return nil
+}
+
+func runCheck(args []string) int {
+	var opts options
+	var thFlags thresholdFlags
+	fs := newFlagSet("check", "Poll every configured consumer once, evaluate the check thresholds and exit\n"+
+		"with 0, 1, 2 or 3 for OK, WARNING, CRITICAL or UNKNOWN.\n"+
+		"Flags override the thresholds of the config file for every consumer.", &opts)
+	thFlags.register(fs)
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return checkUnknown(err)
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return checkUnknown(err)
+	}
+	defer nc.Close()
+
+	poller, err := monitor.NewPoller(nc, cfg.Consumers, monitor.ScheduleFromConfig(cfg), jsOptions)
+	if err != nil {
+		return checkUnknown(err)
+	}
+
+	update := poller.Once()
+	now := time.Now()
+
+	var results []health.Result
+	counts := make(map[health.Status]int)
+	overall := health.OK
+	seen := make(map[config.ConsumerRef]bool)
+	for _, state := range update.States {
+		if seen[state.Ref] {
+			continue
+		}
+		seen[state.Ref] = true
+
+		th := thFlags.apply(fs, cfg.ConsumerThresholds(state.Ref))
+		res := health.Evaluate(state, th, now)
+		results = append(results, res)
+		counts[res.Status]++
+		overall = health.Worse(overall, res.Status)
+	}
+
+	// First line: summary and perfdata, as expected by Nagios and Sensu
+	var perf []string
+	for _, res := range results {
+		for _, m := range res.Metrics {
+			perf = append(perf, perfdata(res.Ref.Key()+" "+m.Name, m))
+		}
+	}
+	fmt.Printf("CONSUMERS %s - %s | %s\n", overall, summarize(counts, len(results)), strings.Join(perf, " "))
+
+	// Long output: one line per consumer that is not OK
+	for _, res := range results {
+		if res.Status != health.OK {
+			fmt.Printf("%s %s: %s\n", res.Status, res.Ref.Key(), strings.Join(res.Messages, ", "))
+		}
+	}
+
+	return int(overall)
+}
+
+// summarize describes how many consumers are in each status.
+func summarize(counts map[health.Status]int, total int) string {
+	var parts []string
+	for _, s := range []health.Status{health.Critical, health.Warning, health.Unknown} {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], strings.ToLower(s.String())))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("%d consumers ok", total)
+	}
+	return fmt.Sprintf("%s of %d consumers", strings.Join(parts, ", "), total)
+}
+
+// perfdata formats a metric as 'label'=value[unit];warn;crit;min
+func perfdata(label string, m health.Metric) string {
+	return fmt.Sprintf("'%s'=%s%s;%s;%s;0",
+		label, formatPerfValue(m.Value), m.Unit, formatThreshold(m.Warning), formatThreshold(m.Critical))
+}
+
+func formatPerfValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatThreshold(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatPerfValue(v)
+}
+
+// checkUnknown reports a failure to run the check itself.
+func checkUnknown(err error) int {
+	fmt.Printf("CONSUMERS %s - %v\n", health.Unknown, err)
+	return int(health.Unknown)
+}
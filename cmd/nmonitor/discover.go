@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+func runDiscover(args []string) int {
+	var opts options
+	fs := newConnectFlagSet("discover", "List the streams and consumers of the account and print them as a consumers config with one window per stream.", &opts)
+	filter := fs.String("stream", "", "only include streams whose name contains this `text`")
+	columns := fs.Int("columns", 4, "columns for each generated window")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return fail(1, err)
+	}
+	defer nc.Close()
+
+	var js jetstream.JetStream
+	switch {
+	case jsOptions.Domain != "":
+		js, err = jetstream.NewWithDomain(nc, jsOptions.Domain)
+	case jsOptions.APIPrefix != "":
+		js, err = jetstream.NewWithAPIPrefix(nc, jsOptions.APIPrefix)
+	default:
+		js, err = jetstream.New(nc)
+	}
+	if err != nil {
+		return fail(1, err)
+	}
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	// A failed listing would print a partial config, so every error fails
+	var streams []string
+	streamNames := js.StreamNames(ctx)
+	for name := range streamNames.Name() {
+		if strings.Contains(name, *filter) {
+			streams = append(streams, name)
+		}
+	}
+	if err := streamNames.Err(); err != nil {
+		return fail(1, fmt.Errorf("list streams: %w", err))
+	}
+	slices.Sort(streams)
+
+	var windows []config.WindowConfig
+	for _, stream := range streams {
+		s, err := js.Stream(ctx, stream)
+		if err != nil {
+			return fail(1, fmt.Errorf("stream %s: %w", stream, err))
+		}
+		var consumers []string
+		consumerNames := s.ConsumerNames(ctx)
+		for name := range consumerNames.Name() {
+			consumers = append(consumers, name)
+		}
+		if err := consumerNames.Err(); err != nil {
+			return fail(1, fmt.Errorf("list consumers of %s: %w", stream, err))
+		}
+		if len(consumers) == 0 {
+			continue
+		}
+		slices.Sort(consumers)
+
+		win := config.WindowConfig{Name: stream, Columns: *columns}
+		for _, name := range consumers {
+			win.Consumers = append(win.Consumers, config.ConsumerRef{Stream: stream, Consumer: name})
+		}
+		windows = append(windows, win)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(struct {
+		Windows []config.WindowConfig `json:"windows"`
+	}{windows}); err != nil {
+		return fail(1, err)
+	}
+	return 0
+}
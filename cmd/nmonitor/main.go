@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,62 +15,166 @@ import (
 
 	"github.com/jrlangford/nats-consumer-monitor/internal/config"
 	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
-	"github.com/jrlangford/nats-consumer-monitor/internal/ui"
 )
 
-const pollInterval = 1 * time.Second
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
+// command is a nmonitor subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"tui", "Run the terminal dashboard (default)", runTUI},
+		{"serve", "Serve the dashboard to browsers", runServe},
+		{"watch", "Print consumer changes to stdout without a UI", runWatch},
+		{"bench", "Measure consumer throughput for a fixed duration", runBench},
+		{"check", "Poll every consumer once and exit with a status code", runCheck},
+		{"discover", "List the streams and consumers of the account as a config", runDiscover},
+		{"version", "Print the version", runVersion},
+		{"help", "Show help for a command", runHelp},
+	}
+}
 
 func main() {
-	// Load configuration
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+		usage(os.Stdout)
+		return 0
+	}
+
+	// Without a command (or with only flags) run the TUI
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runTUI(args)
+	}
+
+	name := args[0]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "nmonitor: unknown command %q\n\n", name)
+	usage(os.Stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: nmonitor [command] [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'nmonitor help <command>' for the flags of a command.\n")
+}
+
+func runVersion(args []string) int {
+	fmt.Printf("nmonitor %s\n", version)
+	return 0
+}
+
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] && cmd.name != "help" {
+			return cmd.run([]string{"-h"})
+		}
+	}
+	fmt.Fprintf(os.Stderr, "nmonitor: unknown command %q\n", args[0])
+	return 2
+}
+
+// options holds the flags shared by every command that talks to NATS.
+type options struct {
+	configPath string
+	context    string
+	interval   time.Duration
+}
+
+// newFlagSet creates the flag set of a command that monitors the
+// configured consumers, with the shared flags.
+func newFlagSet(name, summary string, opts *options) *flag.FlagSet {
+	fs := newConnectFlagSet(name, summary, opts)
 	configPath := os.Getenv("CONSUMERS_CONFIG")
 	if configPath == "" {
 		configPath = "consumers.json"
 	}
+	fs.StringVar(&opts.configPath, "config", configPath, "consumers config `path` (env CONSUMERS_CONFIG)")
+	fs.DurationVar(&opts.interval, "interval", 0, "poll interval, overrides the config file")
+	return fs
+}
 
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		log.Fatal(err)
+// newConnectFlagSet creates the flag set of a command that only talks to
+// NATS, without a consumers config.
+func newConnectFlagSet(name, summary string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: nmonitor %s [flags]\n\n%s\n\nFlags:\n", name, summary)
+		fs.PrintDefaults()
 	}
+	fs.StringVar(&opts.context, "context", os.Getenv("NATS_CONTEXT"), "NATS CLI context `name` (env NATS_CONTEXT)")
+	return fs
+}
 
-	// Connect to NATS
-	natsURL, natsOpts, err := config.LoadNATSFromContext()
-	if err != nil {
-		log.Fatal(err)
+// parseFlags parses args and returns the exit code to use if parsing
+// stopped the command (for -h or a bad flag).
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0, false
+		}
+		return 2, false
 	}
+	return 0, true
+}
 
-	nc, err := nats.Connect(natsURL, natsOpts...)
+// loadConfig loads the consumers config and applies flag overrides.
+func (o *options) loadConfig() (*config.Config, error) {
+	cfg, err := config.Load(o.configPath)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	defer nc.Close()
+	if o.interval > 0 {
+		// The flag overrides the window intervals too
+		cfg.Poll.Interval = config.Duration(o.interval)
+		for i := range cfg.Windows {
+			cfg.Windows[i].PollInterval = 0
+		}
+	}
+	return cfg, nil
+}
 
-	js, err := nc.JetStream()
+// connect opens a NATS connection using the selected context. It also
+// returns the JetStream API the context selects.
+func (o *options) connect() (*nats.Conn, monitor.JetStreamOptions, error) {
+	conn, err := config.LoadNATSContext(o.context)
 	if err != nil {
-		log.Fatal(err)
+		return nil, monitor.JetStreamOptions{}, err
 	}
+	js := monitor.JetStreamOptions{Domain: conn.JetStreamDomain, APIPrefix: conn.JetStreamAPIPrefix}
+	nc, err := nats.Connect(conn.URL, conn.Options...)
+	return nc, js, err
+}
 
-	// Setup context for graceful shutdown
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Handle signals (including Ctrl-C)
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigCh
-		cancel()
-	}()
-
-	// Create update channel
-	updates := make(chan []monitor.ConsumerState)
-
-	// Start poller (polls all consumers from all windows)
-	poller := monitor.NewPoller(js, cfg.Consumers, pollInterval)
-	go poller.Run(ctx, updates)
-
-	// Run UI with multiple windows
-	app := ui.NewApp(cfg.Windows)
-	if err := app.Run(ctx, updates); err != nil {
-		log.Fatal(err)
-	}
+// signalContext returns a context that is cancelled on SIGINT or SIGTERM.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// fail prints an error and returns the given exit code.
+func fail(code int, err error) int {
+	fmt.Fprintf(os.Stderr, "nmonitor: %v\n", err)
+	return code
 }
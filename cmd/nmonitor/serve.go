@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+	"github.com/jrlangford/nats-consumer-monitor/internal/web"
+)
+
+func runServe(args []string) int {
+	var opts options
+	fs := newFlagSet("serve", "Serve the configured windows as a live HTML dashboard.", &opts)
+	listen := fs.String("listen", "127.0.0.1:8080", "HTTP listen `address`; the API has no authentication")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(1, err)
+	}
+
+	baselines, err := baseline.Open(cfg.Throughput.BaselineFile)
+	if err != nil {
+		return fail(1, err)
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return fail(1, err)
+	}
+	defer nc.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	poller, err := monitor.NewPoller(nc, cfg.Consumers, monitor.ScheduleFromConfig(cfg), jsOptions)
+	if err != nil {
+		return fail(1, err)
+	}
+	sub := poller.Subscribe()
+	defer sub.Close()
+	startAlerts(ctx, newAlertManager(ctx, cfg, logError), poller)
+	go poller.Run(ctx)
+
+	srv := web.NewServer(cfg.Windows)
+	srv.SetBaselines(baselines, cfg.Throughput.RegressionThreshold)
+	go srv.Run(ctx, sub)
+
+	fmt.Fprintf(os.Stderr, "nmonitor: serving dashboard on %s\n", *listen)
+	if err := srv.ListenAndServe(ctx, *listen); err != nil {
+		return fail(1, err)
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+	"github.com/jrlangford/nats-consumer-monitor/internal/ui"
+)
+
+func runTUI(args []string) int {
+	var opts options
+	fs := newFlagSet("tui", "Run the terminal dashboard.", &opts)
+	window := fs.String("window", "", "start on the window with this `name` or number")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(1, err)
+	}
+
+	startIdx := 0
+	if *window != "" {
+		if startIdx, err = cfg.WindowIndex(*window); err != nil {
+			return fail(1, err)
+		}
+	}
+
+	baselines, err := baseline.Open(cfg.Throughput.BaselineFile)
+	if err != nil {
+		return fail(1, err)
+	}
+
+	theme, err := ui.LoadTheme(cfg.Theme)
+	if err != nil {
+		return fail(1, err)
+	}
+	keys, err := ui.NewKeyMap(cfg.Keys)
+	if err != nil {
+		return fail(1, err)
+	}
+	app, err := ui.NewApp(cfg.Windows, theme, keys)
+	if err != nil {
+		return fail(1, err)
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return fail(1, err)
+	}
+	defer nc.Close()
+
+	// Setup context for graceful shutdown (including Ctrl-C)
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	// Start poller (polls all consumers from all windows)
+	poller, err := monitor.NewPoller(nc, cfg.Consumers, monitor.ScheduleFromConfig(cfg), jsOptions)
+	if err != nil {
+		return fail(1, err)
+	}
+	sub := poller.Subscribe()
+	defer sub.Close()
+
+	// Run UI with multiple windows
+	app.SelectWindow(startIdx)
+	app.SetNotifications(cfg.Alerts.Terminal)
+	app.SetThroughput(cfg.Throughput)
+	app.SetBaselines(baselines, cfg.Throughput.RegressionThreshold)
+	app.SetHealth(cfg)
+	app.SetPoller(poller)
+
+	// Alerts and delivery errors show up in the status bar
+	alerts := newAlertManager(ctx, cfg, app.ReportError)
+	alerts.Add(app)
+	startAlerts(ctx, alerts, poller)
+	go poller.Run(ctx)
+
+	if err := app.Run(ctx, sub); err != nil {
+		return fail(1, err)
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// watchRecord is the JSON form of a watch line.
+type watchRecord struct {
+	Time        time.Time `json:"time"`
+	Stream      string    `json:"stream"`
+	Consumer    string    `json:"consumer"`
+	Error       string    `json:"error,omitempty"`
+	Delivered   uint64    `json:"delivered"`
+	Acked       uint64    `json:"acked"`
+	Pending     uint64    `json:"pending"`
+	AckPending  int       `json:"ack_pending"`
+	Redelivered int       `json:"redelivered"`
+	Waiting     int       `json:"waiting"`
+}
+
+func runWatch(args []string) int {
+	var opts options
+	fs := newFlagSet("watch", "Poll consumers and print a line for every change, without a UI.", &opts)
+	all := fs.Bool("all", false, "print every consumer on every poll, not only changes")
+	asJSON := fs.Bool("json", false, "print JSON lines instead of text")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return fail(1, err)
+	}
+
+	nc, jsOptions, err := opts.connect()
+	if err != nil {
+		return fail(1, err)
+	}
+	defer nc.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	poller, err := monitor.NewPoller(nc, cfg.Consumers, monitor.ScheduleFromConfig(cfg), jsOptions)
+	if err != nil {
+		return fail(1, err)
+	}
+	sub := poller.Subscribe()
+	defer sub.Close()
+	startAlerts(ctx, newAlertManager(ctx, cfg, logError), poller)
+	go poller.Run(ctx)
+
+	enc := json.NewEncoder(os.Stdout)
+	first := true
+	seen := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case update := <-sub.C:
+			for _, state := range update.States {
+				key := state.Ref.Key()
+				// The config may list a consumer in several windows
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				if !*all && !first && !state.Changed && state.Error == nil {
+					continue
+				}
+				if *asJSON {
+					_ = enc.Encode(newWatchRecord(state))
+				} else {
+					fmt.Println(formatWatchLine(state))
+				}
+			}
+			clear(seen)
+			first = false
+		}
+	}
+}
+
+func newWatchRecord(state monitor.ConsumerState) watchRecord {
+	rec := watchRecord{
+		Time:        state.PolledAt,
+		Stream:      state.Ref.Stream,
+		Consumer:    state.Ref.Consumer,
+		Delivered:   state.Snapshot.DeliveredConsumer,
+		Acked:       state.Snapshot.AckConsumer,
+		Pending:     state.Snapshot.NumPending,
+		AckPending:  state.Snapshot.NumAckPending,
+		Redelivered: state.Snapshot.NumRedelivered,
+		Waiting:     state.Snapshot.NumWaiting,
+	}
+	if state.Error != nil {
+		rec.Error = state.Error.Error()
+	}
+	return rec
+}
+
+func formatWatchLine(state monitor.ConsumerState) string {
+	ts := state.PolledAt.Format(time.TimeOnly)
+	if state.Error != nil {
+		return fmt.Sprintf("%s %s ERROR %v", ts, state.Ref.Key(), state.Error)
+	}
+	s := state.Snapshot
+	return fmt.Sprintf("%s %s delivered=%d acked=%d pending=%d ack_pending=%d redelivered=%d waiting=%d",
+		ts, state.Ref.Key(), s.DeliveredConsumer, s.AckConsumer, s.NumPending, s.NumAckPending, s.NumRedelivered, s.NumWaiting)
+}
@@ -6,6 +6,7 @@ require (
 	github.com/gdamore/tcell/v2 v2.13.4
 	github.com/nats-io/nats.go v1.48.0
 	github.com/rivo/tview v0.42.0
+	github.com/rivo/uniseg v0.4.7
 )
 
 require (
@@ -14,7 +15,6 @@ require (
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
 	github.com/nats-io/nkeys v0.4.11 // indirect
 	github.com/nats-io/nuid v1.0.1 // indirect
-	github.com/rivo/uniseg v0.4.7 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/term v0.37.0 // indirect
@@ -0,0 +1,89 @@
+// Package alert detects consumer health transitions and delivers
+// notifications about them.
+package alert
+
+import (
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// Kind is a condition a consumer can alert on.
+type Kind string
+
+const (
+	KindError      Kind = "error"      // The consumer could not be fetched
+	KindStalled    Kind = "stalled"    // No ack progress while messages are outstanding
+	KindBacklog    Kind = "backlog"    // Unprocessed messages over the threshold
+	KindRedelivery Kind = "redelivery" // Redelivered messages grew quickly
+)
+
+// Severity returns how urgent an alert of this kind is.
+func (k Kind) Severity() string {
+	switch k {
+	case KindError, KindStalled:
+		return "critical"
+	default:
+		return "warning"
+	}
+}
+
+// Status is whether an alert is active.
+type Status string
+
+const (
+	Firing   Status = "firing"
+	Resolved Status = "resolved"
+)
+
+// Values are the metrics of a consumer at the time of an event.
+type Values struct {
+	Delivered   uint64  `json:"delivered"`
+	Acked       uint64  `json:"acked"`
+	Pending     uint64  `json:"pending"`
+	AckPending  int     `json:"ack_pending"`
+	Redelivered int     `json:"redelivered"`
+	Waiting     int     `json:"waiting"`
+	LastAckAge  float64 `json:"last_ack_age_seconds"`
+}
+
+// Event is a transition of a consumer into or out of an alerting condition.
+type Event struct {
+	Ref      config.ConsumerRef `json:"-"`
+	Kind     Kind               `json:"kind"`
+	Status   Status             `json:"status"`
+	Message  string             `json:"message"`
+	StartsAt time.Time          `json:"starts_at"`
+	EndsAt   time.Time          `json:"ends_at,omitzero"`
+	Values   Values             `json:"values"`
+	Previous Values             `json:"previous"` // Values at the previous poll
+}
+
+// Key identifies the alert an event belongs to.
+func (e Event) Key() string {
+	return e.Ref.Key() + "/" + string(e.Kind)
+}
+
+// newValues extracts the alerting metrics of a consumer state.
+func newValues(state monitor.ConsumerState, now time.Time) Values {
+	s := state.Snapshot
+	v := Values{
+		Delivered:   s.DeliveredConsumer,
+		Acked:       s.AckConsumer,
+		Pending:     s.NumPending,
+		AckPending:  s.NumAckPending,
+		Redelivered: s.NumRedelivered,
+		Waiting:     s.NumWaiting,
+	}
+	if ci := state.Info; ci != nil {
+		last := ci.Created
+		if ci.AckFloor.Last != nil && !ci.AckFloor.Last.IsZero() {
+			last = *ci.AckFloor.Last
+		}
+		if !last.IsZero() {
+			v.LastAckAge = now.Sub(last).Round(time.Second).Seconds()
+		}
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// redeliverySample is the redelivered count seen at a point in time.
+type redeliverySample struct {
+	at    time.Time
+	count int
+}
+
+// consumerHistory is what the detector remembers about a consumer.
+type consumerHistory struct {
+	lastAck      uint64
+	lastProgress time.Time // Last time the ack floor moved
+	previous     Values
+	redeliveries []redeliverySample
+	active       map[Kind]*Event
+}
+
+// Detector turns poll updates into alert transitions. It reports an event
+// when a consumer enters a condition and again when it leaves it.
+type Detector struct {
+	cfg config.AlertConfig
+
+	mu        sync.Mutex
+	consumers map[string]*consumerHistory // keyed by "stream/consumer"
+}
+
+// NewDetector creates a detector for the given alert rules.
+func NewDetector(cfg config.AlertConfig) *Detector {
+	return &Detector{
+		cfg:       cfg,
+		consumers: make(map[string]*consumerHistory),
+	}
+}
+
+// Observe evaluates an update and returns the transitions it caused.
+func (d *Detector) Observe(update monitor.Update) []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := update.Stats.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var events []Event
+	seen := make(map[string]bool)
+	for _, state := range update.States {
+		key := state.Ref.Key()
+		// The config may list a consumer in several windows
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		h, ok := d.consumers[key]
+		if !ok {
+			h = &consumerHistory{lastProgress: now, active: make(map[Kind]*Event)}
+			d.consumers[key] = h
+		}
+		events = append(events, d.evaluate(state, h, now)...)
+	}
+	return events
+}
+
+// Active returns the alerts that are currently firing.
+func (d *Detector) Active() []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var active []Event
+	for _, h := range d.consumers {
+		for _, e := range h.active {
+			active = append(active, *e)
+		}
+	}
+	return active
+}
+
+// evaluate checks every condition of a consumer.
+func (d *Detector) evaluate(state monitor.ConsumerState, h *consumerHistory, now time.Time) []Event {
+	var events []Event
+	values := h.previous
+
+	set := func(kind Kind, firing bool, message string) {
+		e, active := h.active[kind]
+		switch {
+		case firing && !active:
+			e = &Event{
+				Ref:      state.Ref,
+				Kind:     kind,
+				Status:   Firing,
+				Message:  message,
+				StartsAt: now,
+				Values:   values,
+				Previous: h.previous,
+			}
+			h.active[kind] = e
+			events = append(events, *e)
+		case firing && active:
+			// Keep the latest values so repeats report current numbers
+			e.Message = message
+			e.Values = values
+			e.Previous = h.previous
+		case !firing && active:
+			delete(h.active, kind)
+			resolved := *e
+			resolved.Status = Resolved
+			resolved.EndsAt = now
+			resolved.Values = values
+			resolved.Previous = h.previous
+			events = append(events, resolved)
+		}
+	}
+
+	if state.Error != nil {
+		// Other conditions can't be evaluated without data; leave them as they are
+		set(KindError, true, state.Error.Error())
+		return events
+	}
+	if state.Info == nil {
+		return events
+	}
+
+	values = newValues(state, now)
+	set(KindError, false, "")
+
+	snap := state.Snapshot
+	if snap.AckConsumer != h.lastAck {
+		h.lastAck = snap.AckConsumer
+		h.lastProgress = now
+	}
+
+	if stall := d.cfg.StallAfter.Duration(); stall > 0 {
+		outstanding := snap.NumPending > 0 || snap.NumAckPending > 0
+		idle := now.Sub(h.lastProgress)
+		set(KindStalled, outstanding && idle >= stall,
+			fmt.Sprintf("no ack progress for %s with %d pending and %d awaiting ack", idle.Round(time.Second), snap.NumPending, snap.NumAckPending))
+	}
+
+	if d.cfg.Backlog > 0 {
+		set(KindBacklog, snap.NumPending >= d.cfg.Backlog,
+			fmt.Sprintf("%d unprocessed messages (threshold %d)", snap.NumPending, d.cfg.Backlog))
+	}
+
+	if spike := d.cfg.RedeliverySpike; spike.Count > 0 {
+		window := spike.Window.Duration()
+		h.redeliveries = append(h.redeliveries, redeliverySample{at: now, count: snap.NumRedelivered})
+		for len(h.redeliveries) > 1 && now.Sub(h.redeliveries[0].at) > window {
+			h.redeliveries = h.redeliveries[1:]
+		}
+		lowest := snap.NumRedelivered
+		for _, s := range h.redeliveries {
+			lowest = min(lowest, s.count)
+		}
+		growth := snap.NumRedelivered - lowest
+		set(KindRedelivery, growth >= spike.Count,
+			fmt.Sprintf("redelivered grew by %d within %s", growth, window))
+	}
+
+	h.previous = values
+	return events
+}
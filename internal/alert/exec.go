@@ -0,0 +1,213 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+const (
+	hookQueueSize = 256
+	// maxHookOutput caps how much of a hook's stdout and stderr is kept.
+	maxHookOutput = 16 * 1024
+	// hookWaitDelay bounds how long to wait for a killed hook's children to
+	// release its output pipes.
+	hookWaitDelay = 2 * time.Second
+)
+
+// HookResult describes a finished hook run.
+type HookResult struct {
+	Command  string
+	Event    Event
+	Duration time.Duration
+	Output   string // Combined stdout and stderr, truncated to 16 KiB
+	Err      error  // Non-nil if the hook failed, timed out or could not start
+}
+
+// hookJob is a queued hook run.
+type hookJob struct {
+	hook  config.HookConfig
+	event Event
+}
+
+// Exec runs shell commands on alert transitions. Hooks run on a bounded
+// pool with a timeout each, and their output is captured rather than
+// written to the terminal.
+type Exec struct {
+	hooks    []config.HookConfig
+	workers  int
+	queue    chan hookJob
+	onResult func(HookResult)
+}
+
+// NewExec creates an exec notifier. onResult, if not nil, is called after
+// every hook run.
+func NewExec(hooks []config.HookConfig, workers int, onResult func(HookResult)) *Exec {
+	if onResult == nil {
+		onResult = func(HookResult) {}
+	}
+	return &Exec{
+		hooks:    hooks,
+		workers:  max(workers, 1),
+		queue:    make(chan hookJob, hookQueueSize),
+		onResult: onResult,
+	}
+}
+
+// Notify queues the hooks matching each event. If the queue is full the
+// run is dropped and reported as failed.
+func (x *Exec) Notify(events []Event) {
+	for _, e := range events {
+		for _, h := range x.hooks {
+			if !hookMatches(h, e) {
+				continue
+			}
+			select {
+			case x.queue <- hookJob{hook: h, event: e}:
+			default:
+				x.onResult(HookResult{Command: h.Command, Event: e, Err: fmt.Errorf("hook queue full, run dropped")})
+			}
+		}
+	}
+}
+
+// Run executes queued hooks until the context is cancelled.
+func (x *Exec) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range x.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-x.queue:
+					x.onResult(runHook(ctx, job.hook, job.event))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// hookMatches reports whether a hook wants to run for an event.
+func hookMatches(h config.HookConfig, e Event) bool {
+	if len(h.Kinds) > 0 && !slices.Contains(h.Kinds, string(e.Kind)) {
+		return false
+	}
+	if len(h.On) > 0 && !slices.Contains(h.On, string(e.Status)) {
+		return false
+	}
+	return true
+}
+
+// runHook runs a single hook with the event in its environment and as JSON
+// on stdin.
+func runHook(ctx context.Context, h config.HookConfig, e Event) HookResult {
+	res := HookResult{Command: h.Command, Event: e}
+
+	stdin, err := json.Marshal(webhookAlert{
+		Stream:   e.Ref.Stream,
+		Consumer: e.Ref.Consumer,
+		Severity: e.Kind.Severity(),
+		Event:    e,
+	})
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout.Duration())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", h.Command)
+	cmd.Env = append(os.Environ(), hookEnv(e)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	out := &limitedBuffer{limit: maxHookOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = hookWaitDelay
+	killProcessGroup(cmd)
+
+	start := time.Now()
+	err = cmd.Run()
+	res.Duration = time.Since(start)
+	res.Output = out.String()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", h.Timeout.Duration())
+	}
+	res.Err = err
+	return res
+}
+
+// hookEnv returns the NMONITOR_* environment variables of an event.
+func hookEnv(e Event) []string {
+	env := []string{
+		"NMONITOR_STREAM=" + e.Ref.Stream,
+		"NMONITOR_CONSUMER=" + e.Ref.Consumer,
+		"NMONITOR_KIND=" + string(e.Kind),
+		"NMONITOR_STATE=" + string(e.Status),
+		"NMONITOR_SEVERITY=" + e.Kind.Severity(),
+		"NMONITOR_MESSAGE=" + e.Message,
+		"NMONITOR_STARTS_AT=" + e.StartsAt.Format(time.RFC3339),
+	}
+	if !e.EndsAt.IsZero() {
+		env = append(env, "NMONITOR_ENDS_AT="+e.EndsAt.Format(time.RFC3339))
+	}
+	env = append(env, valuesEnv("NMONITOR_", e.Values)...)
+	env = append(env, valuesEnv("NMONITOR_PREV_", e.Previous)...)
+	return env
+}
+
+func valuesEnv(prefix string, v Values) []string {
+	return []string{
+		prefix + "DELIVERED=" + strconv.FormatUint(v.Delivered, 10),
+		prefix + "ACKED=" + strconv.FormatUint(v.Acked, 10),
+		prefix + "PENDING=" + strconv.FormatUint(v.Pending, 10),
+		prefix + "ACK_PENDING=" + strconv.Itoa(v.AckPending),
+		prefix + "REDELIVERED=" + strconv.Itoa(v.Redelivered),
+		prefix + "WAITING=" + strconv.Itoa(v.Waiting),
+		prefix + "LAST_ACK_AGE=" + strconv.FormatFloat(v.LastAckAge, 'f', -1, 64),
+	}
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards
+// the rest, so a chatty hook can't exhaust memory.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package alert
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup runs the hook in its own process group and kills the
+// whole group on timeout, so children of the shell don't outlive it.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,80 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// Notifier receives alert transitions. Notify must not block; slow
+// delivery belongs in the notifier's own goroutine.
+type Notifier interface {
+	Notify(events []Event)
+}
+
+// Refresher is a notifier that keeps firing alerts to notify again. After
+// every poll it receives the active alerts, with their current values.
+type Refresher interface {
+	Refresh(active []Event)
+}
+
+// Manager feeds poll updates through a detector and hands the resulting
+// events to every notifier.
+type Manager struct {
+	detector  *Detector
+	notifiers []Notifier
+}
+
+// NewManager creates a manager that notifies the given notifiers.
+func NewManager(detector *Detector, notifiers ...Notifier) *Manager {
+	return &Manager{detector: detector, notifiers: notifiers}
+}
+
+// Add registers another notifier. It must be called before Run.
+func (m *Manager) Add(n Notifier) {
+	m.notifiers = append(m.notifiers, n)
+}
+
+// Detector returns the detector of the manager.
+func (m *Manager) Detector() *Detector {
+	return m.detector
+}
+
+// Run observes updates until the context is cancelled or the subscription
+// is closed.
+func (m *Manager) Run(ctx context.Context, sub *monitor.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			events := m.detector.Observe(update)
+			m.refresh()
+			if len(events) == 0 {
+				continue
+			}
+			for _, n := range m.notifiers {
+				n.Notify(events)
+			}
+		}
+	}
+}
+
+// refresh hands the active alerts to the notifiers that resend them.
+func (m *Manager) refresh() {
+	var active []Event
+	fetched := false
+	for _, n := range m.notifiers {
+		r, ok := n.(Refresher)
+		if !ok {
+			continue
+		}
+		if !fetched {
+			active, fetched = m.detector.Active(), true
+		}
+		r.Refresh(active)
+	}
+}
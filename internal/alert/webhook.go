@@ -0,0 +1,377 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+const (
+	flushInterval  = 1 * time.Second
+	initialBackoff = 1 * time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// group batches the alerts that share the webhook's group_by labels.
+type group struct {
+	key      string
+	labels   map[string]string
+	firing   map[string]Event // keyed by Event.Key
+	resolved map[string]Event // resolved alerts not yet notified
+	notified map[string]bool  // firing alerts receivers were told about
+	inflight map[string]bool  // firing alerts of the notification being sent
+	pending  bool             // has changes that haven't been sent
+	flushAt  time.Time
+	lastSent time.Time
+	sending  bool
+}
+
+// Webhook POSTs alert notifications to an HTTP endpoint. Alerts are grouped
+// by the configured labels, firing alerts are resent every repeat interval,
+// and failed requests are retried with exponential backoff.
+type Webhook struct {
+	cfg     config.WebhookConfig
+	client  *http.Client
+	onError func(error)
+
+	mu     sync.Mutex
+	groups map[string]*group
+}
+
+// NewWebhook creates a webhook notifier. onError, if not nil, is called
+// when a notification could not be delivered after all retries.
+func NewWebhook(cfg config.WebhookConfig, onError func(error)) *Webhook {
+	if onError == nil {
+		onError = func(error) {}
+	}
+	return &Webhook{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout.Duration()},
+		onError: onError,
+		groups:  make(map[string]*group),
+	}
+}
+
+// Notify queues events for delivery.
+func (w *Webhook) Notify(events []Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range events {
+		labels := w.groupLabels(e)
+		key := groupKey(labels)
+		g, ok := w.groups[key]
+		if !ok {
+			g = &group{
+				key:      key,
+				labels:   labels,
+				firing:   make(map[string]Event),
+				resolved: make(map[string]Event),
+				notified: make(map[string]bool),
+			}
+			w.groups[key] = g
+		}
+
+		if e.Status == Firing {
+			g.firing[e.Key()] = e
+			delete(g.resolved, e.Key())
+		} else {
+			delete(g.firing, e.Key())
+			// An alert that resolved before it was ever sent would be news
+			// to the receiver; drop it
+			if !*w.cfg.SendResolved || !g.notified[e.Key()] && !g.inflight[e.Key()] {
+				delete(g.notified, e.Key())
+				continue
+			}
+			g.resolved[e.Key()] = e
+		}
+
+		if !g.pending {
+			g.pending = true
+			g.flushAt = now.Add(w.cfg.GroupWait.Duration())
+		}
+	}
+}
+
+// Refresh replaces the queued firing alerts with the active ones of the
+// detector, so repeats report current numbers.
+func (w *Webhook) Refresh(active []Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, e := range active {
+		g, ok := w.groups[groupKey(w.groupLabels(e))]
+		if !ok {
+			continue
+		}
+		if _, firing := g.firing[e.Key()]; firing {
+			g.firing[e.Key()] = e
+		}
+	}
+}
+
+// Run delivers queued notifications until the context is cancelled.
+func (w *Webhook) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.flush(ctx, now)
+		}
+	}
+}
+
+// flush sends every group that is due.
+func (w *Webhook) flush(ctx context.Context, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	repeat := w.cfg.RepeatInterval.Duration()
+	for key, g := range w.groups {
+		if g.sending {
+			continue
+		}
+		due := g.pending && !now.Before(g.flushAt)
+		repeatDue := len(g.firing) > 0 && !g.lastSent.IsZero() && now.Sub(g.lastSent) >= repeat
+		if !due && !repeatDue {
+			if len(g.firing) == 0 && len(g.resolved) == 0 && len(g.notified) == 0 && !g.pending {
+				delete(w.groups, key)
+			}
+			continue
+		}
+		if len(g.firing) == 0 && len(g.resolved) == 0 {
+			g.pending = false // Everything resolved before it was sent
+			continue
+		}
+
+		var alerts []Event
+		g.inflight = make(map[string]bool, len(g.firing))
+		for key, e := range g.firing {
+			alerts = append(alerts, e)
+			g.inflight[key] = true
+		}
+		for _, e := range g.resolved {
+			alerts = append(alerts, e)
+		}
+		slices.SortFunc(alerts, func(a, b Event) int {
+			return strings.Compare(a.Key(), b.Key())
+		})
+
+		resolved := g.resolved
+		g.resolved = make(map[string]Event)
+		g.pending = false
+		g.sending = true
+
+		go w.deliver(ctx, g, alerts, resolved)
+	}
+}
+
+// deliver sends one notification and records the outcome on the group.
+func (w *Webhook) deliver(ctx context.Context, g *group, alerts []Event, resolved map[string]Event) {
+	err := w.send(ctx, g, alerts)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	g.sending = false
+	inflight := g.inflight
+	g.inflight = nil
+	if err == nil {
+		g.lastSent = time.Now()
+		for key := range inflight {
+			g.notified[key] = true
+		}
+		for key := range resolved {
+			delete(g.notified, key)
+		}
+		return
+	}
+
+	w.onError(fmt.Errorf("webhook %s: %w", w.cfg.URL, err))
+	// Alerts that resolved while their first notification failed were
+	// never seen
+	for key := range g.resolved {
+		if !g.notified[key] {
+			delete(g.resolved, key)
+		}
+	}
+	// Keep resolved alerts that weren't superseded and try again later
+	for key, e := range resolved {
+		if _, firing := g.firing[key]; !firing {
+			if _, ok := g.resolved[key]; !ok {
+				g.resolved[key] = e
+			}
+		}
+	}
+	if !g.pending {
+		g.pending = true
+		g.flushAt = time.Now().Add(w.cfg.GroupWait.Duration())
+	}
+}
+
+// send POSTs the alerts, retrying with exponential backoff.
+func (w *Webhook) send(ctx context.Context, g *group, alerts []Event) error {
+	var body []byte
+	var err error
+	if w.cfg.Format == config.WebhookFormatAlertmanager {
+		body, err = json.Marshal(alertmanagerPayload(alerts))
+	} else {
+		body, err = json.Marshal(jsonPayload(g, alerts))
+	}
+	if err != nil {
+		return err
+	}
+
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= *w.cfg.MaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// post makes a single request. It reports whether a failure is worth
+// retrying.
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("unexpected status %s", resp.Status)
+}
+
+// groupLabels returns the labels an event is grouped by.
+func (w *Webhook) groupLabels(e Event) map[string]string {
+	labels := make(map[string]string)
+	for _, name := range w.cfg.GroupBy {
+		labels[name] = eventLabels(e)[name]
+	}
+	return labels
+}
+
+// eventLabels returns the identifying labels of an event.
+func eventLabels(e Event) map[string]string {
+	return map[string]string{
+		"stream":   e.Ref.Stream,
+		"consumer": e.Ref.Consumer,
+		"kind":     string(e.Kind),
+	}
+}
+
+// groupKey builds a stable key from group labels.
+func groupKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + "=" + labels[name]
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// webhookAlert is an alert in the native JSON payload.
+type webhookAlert struct {
+	Stream   string `json:"stream"`
+	Consumer string `json:"consumer"`
+	Severity string `json:"severity"`
+	Event
+}
+
+// webhookPayload is the native JSON payload.
+type webhookPayload struct {
+	Status      Status            `json:"status"` // Firing if any alert in the group is firing
+	GroupKey    string            `json:"group_key"`
+	GroupLabels map[string]string `json:"group_labels"`
+	Alerts      []webhookAlert    `json:"alerts"`
+}
+
+func jsonPayload(g *group, alerts []Event) webhookPayload {
+	payload := webhookPayload{
+		Status:      Resolved,
+		GroupKey:    g.key,
+		GroupLabels: g.labels,
+	}
+	for _, e := range alerts {
+		if e.Status == Firing {
+			payload.Status = Firing
+		}
+		payload.Alerts = append(payload.Alerts, webhookAlert{
+			Stream:   e.Ref.Stream,
+			Consumer: e.Ref.Consumer,
+			Severity: e.Kind.Severity(),
+			Event:    e,
+		})
+	}
+	return payload
+}
+
+// amAlert is an alert in the Alertmanager v2 API format.
+type amAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt,omitzero"`
+}
+
+// alertmanagerPayload formats alerts for POST /api/v2/alerts.
+func alertmanagerPayload(alerts []Event) []amAlert {
+	payload := make([]amAlert, len(alerts))
+	for i, e := range alerts {
+		labels := eventLabels(e)
+		labels["alertname"] = "NATSConsumer" + strings.ToUpper(string(e.Kind[:1])) + string(e.Kind[1:])
+		labels["severity"] = e.Kind.Severity()
+		payload[i] = amAlert{
+			Labels: labels,
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s %s: %s", e.Ref.Key(), e.Kind, e.Message),
+			},
+			StartsAt: e.StartsAt,
+			EndsAt:   e.EndsAt,
+		}
+	}
+	return payload
+}
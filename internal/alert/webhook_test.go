@@ -0,0 +1,135 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// testWebhook returns a webhook posting to a test server, and the payloads
+// the server receives.
+func testWebhook(t *testing.T) (*Webhook, <-chan webhookPayload) {
+	t.Helper()
+	payloads := make(chan webhookPayload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		payloads <- p
+	}))
+	t.Cleanup(srv.Close)
+
+	sendResolved, maxRetries := true, 0
+	cfg := config.WebhookConfig{
+		URL:            srv.URL,
+		Format:         config.WebhookFormatJSON,
+		GroupWait:      config.Duration(time.Second),
+		RepeatInterval: config.Duration(time.Hour),
+		SendResolved:   &sendResolved,
+		MaxRetries:     &maxRetries,
+		Timeout:        config.Duration(5 * time.Second),
+	}
+	return NewWebhook(cfg, func(err error) { t.Errorf("deliver: %v", err) }), payloads
+}
+
+// flushAt flushes the webhook as if at now and waits until the
+// notifications it started are delivered.
+func flushAt(w *Webhook, now time.Time) {
+	w.flush(context.Background(), now)
+	for {
+		w.mu.Lock()
+		sending := false
+		for _, g := range w.groups {
+			sending = sending || g.sending
+		}
+		w.mu.Unlock()
+		if !sending {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// received returns the payload the server received, or false if there was
+// none.
+func received(payloads <-chan webhookPayload) (webhookPayload, bool) {
+	select {
+	case p := <-payloads:
+		return p, true
+	default:
+		return webhookPayload{}, false
+	}
+}
+
+func event(status Status, pending uint64) Event {
+	return Event{
+		Ref:    config.ConsumerRef{Stream: "ORDERS", Consumer: "worker"},
+		Kind:   KindBacklog,
+		Status: status,
+		Values: Values{Pending: pending},
+	}
+}
+
+func TestWebhookResolvedAfterFiring(t *testing.T) {
+	w, payloads := testWebhook(t)
+	later := time.Now().Add(time.Hour)
+
+	w.Notify([]Event{event(Firing, 100)})
+	flushAt(w, later)
+	p, ok := received(payloads)
+	if !ok || p.Status != Firing || len(p.Alerts) != 1 {
+		t.Fatalf("first notification = %+v, %v, want one firing alert", p, ok)
+	}
+
+	w.Notify([]Event{event(Resolved, 0)})
+	flushAt(w, later.Add(time.Second))
+	p, ok = received(payloads)
+	if !ok || p.Status != Resolved || len(p.Alerts) != 1 || p.Alerts[0].Status != Resolved {
+		t.Fatalf("second notification = %+v, %v, want one resolved alert", p, ok)
+	}
+}
+
+func TestWebhookDropsAlertsResolvedBeforeSent(t *testing.T) {
+	w, payloads := testWebhook(t)
+
+	// Fires and resolves within group_wait: the receiver never hears of it
+	w.Notify([]Event{event(Firing, 100)})
+	w.Notify([]Event{event(Resolved, 0)})
+	flushAt(w, time.Now().Add(time.Hour))
+	if p, ok := received(payloads); ok {
+		t.Fatalf("sent %+v, want nothing", p)
+	}
+	// The empty group is collected on the next flush
+	flushAt(w, time.Now().Add(2*time.Hour))
+	if len(w.groups) != 0 {
+		t.Errorf("%d groups left, want none", len(w.groups))
+	}
+}
+
+func TestWebhookRepeatsCurrentValues(t *testing.T) {
+	w, payloads := testWebhook(t)
+	now := time.Now()
+
+	w.Notify([]Event{event(Firing, 100)})
+	flushAt(w, now.Add(time.Hour))
+	if _, ok := received(payloads); !ok {
+		t.Fatal("firing alert not sent")
+	}
+
+	// The detector keeps the values of active alerts current
+	w.Refresh([]Event{event(Firing, 250)})
+	flushAt(w, time.Now().Add(w.cfg.RepeatInterval.Duration()+time.Second))
+	p, ok := received(payloads)
+	if !ok || len(p.Alerts) != 1 {
+		t.Fatalf("repeat = %+v, %v, want one alert", p, ok)
+	}
+	if got := p.Alerts[0].Values.Pending; got != 250 {
+		t.Errorf("repeated pending = %d, want 250", got)
+	}
+}
@@ -0,0 +1,256 @@
+// Package baseline stores named throughput measurements in a local state
+// file and compares later measurements against them.
+package baseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// Rate is the saved throughput of one consumer.
+type Rate struct {
+	Delivered     uint64  `json:"delivered"`
+	Acked         uint64  `json:"acked"`
+	DeliveredRate float64 `json:"delivered_rate"`
+	AckedRate     float64 `json:"acked_rate"`
+}
+
+// Baseline is a saved throughput measurement.
+type Baseline struct {
+	Name      string          `json:"-"`
+	SavedAt   time.Time       `json:"saved_at"`
+	Duration  float64         `json:"duration_seconds"` // Longest measurement of the consumers
+	Consumers map[string]Rate `json:"consumers"`        // keyed by "stream/consumer"
+}
+
+// stateFile is the on-disk layout of the store.
+type stateFile struct {
+	Baselines map[string]*Baseline `json:"baselines"`
+}
+
+// Store is a set of named baselines backed by a JSON file. It is safe for
+// concurrent use.
+type Store struct {
+	path string
+
+	mu        sync.RWMutex
+	baselines map[string]*Baseline
+}
+
+// DefaultPath returns the state file used when none is configured:
+// $XDG_STATE_HOME/nmonitor/baselines.json, or ~/.local/state/nmonitor/baselines.json.
+func DefaultPath() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "nmonitor", "baselines.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "nmonitor", "baselines.json"), nil
+}
+
+// Open loads the baselines from path, or from DefaultPath if path is
+// empty. A missing file is an empty store.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &Store{path: path, baselines: make(map[string]*Baseline)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read baselines: %w", err)
+	}
+
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse baselines %s: %w", path, err)
+	}
+	for name, b := range file.Baselines {
+		b.Name = name
+		s.baselines[name] = b
+	}
+	return s, nil
+}
+
+// Path returns the state file of the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Names returns the names of the saved baselines, sorted.
+func (s *Store) Names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Sorted(maps.Keys(s.baselines))
+}
+
+// Get returns the baseline with the given name.
+func (s *Store) Get(name string) (*Baseline, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.baselines[name]
+	return b, ok
+}
+
+// Save stores finished measurements, keyed by "stream/consumer", under
+// name, replacing any baseline with that name, and writes the state file.
+func (s *Store) Save(name string, measurements map[string]monitor.ThroughputMeasurement) (*Baseline, error) {
+	if name == "" {
+		return nil, fmt.Errorf("baseline name is empty")
+	}
+	if len(measurements) == 0 {
+		return nil, fmt.Errorf("no throughput results to save")
+	}
+
+	b := &Baseline{Name: name, SavedAt: time.Now(), Consumers: make(map[string]Rate, len(measurements))}
+	for key, m := range measurements {
+		if m.EndTime.IsZero() {
+			return nil, fmt.Errorf("measurement still running")
+		}
+		b.Duration = max(b.Duration, m.Duration().Seconds())
+		b.Consumers[key] = Rate{
+			Delivered:     m.DeliveredCount(),
+			Acked:         m.AckedCount(),
+			DeliveredRate: m.DeliveredRate(),
+			AckedRate:     m.AckedRate(),
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, had := s.baselines[name]
+	s.baselines[name] = b
+	if err := s.write(); err != nil {
+		// Keep the baseline this one was to replace
+		if had {
+			s.baselines[name] = prev
+		} else {
+			delete(s.baselines, name)
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// write replaces the state file. The caller must hold the lock.
+func (s *Store) write() error {
+	data, err := json.MarshalIndent(stateFile{Baselines: s.baselines}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("write baselines: %w", err)
+	}
+
+	// Write to a temporary file first so a crash can't truncate the state
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write baselines: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write baselines: %w", err)
+	}
+	return nil
+}
+
+// Change compares a current rate with its baseline.
+type Change struct {
+	Baseline  float64
+	Current   float64
+	Delta     float64 // Current minus baseline
+	Percent   float64 // Delta relative to the baseline; 0 if the baseline is 0
+	Regressed bool    // Dropped by more than the regression threshold
+}
+
+func newChange(base, current, threshold float64) Change {
+	c := Change{Baseline: base, Current: current, Delta: current - base}
+	if base != 0 {
+		c.Percent = c.Delta / base * 100
+		c.Regressed = c.Percent < -threshold
+	}
+	return c
+}
+
+// Comparison is the change of a consumer, or of the total of a window,
+// against a baseline.
+type Comparison struct {
+	Ref       config.ConsumerRef // Zero for a window total
+	Delivered Change
+	Acked     Change
+}
+
+// WindowComparison compares the consumers of a window and their total.
+// Only consumers that are both in the baseline and measured count.
+type WindowComparison struct {
+	Name      string
+	Consumers []Comparison
+	Total     Comparison
+}
+
+// Regressed reports whether the delivered or acked rate regressed.
+func (c Comparison) Regressed() bool {
+	return c.Delivered.Regressed || c.Acked.Regressed
+}
+
+// Compare compares a consumer measurement with the baseline. threshold is
+// the drop in percent that counts as a regression. Returns false if the
+// baseline doesn't have the consumer.
+func (b *Baseline) Compare(ref config.ConsumerRef, m monitor.ThroughputMeasurement, threshold float64) (Comparison, bool) {
+	base, ok := b.Consumers[ref.Key()]
+	if !ok {
+		return Comparison{}, false
+	}
+	return Comparison{
+		Ref:       ref,
+		Delivered: newChange(base.DeliveredRate, m.DeliveredRate(), threshold),
+		Acked:     newChange(base.AckedRate, m.AckedRate(), threshold),
+	}, true
+}
+
+// CompareWindow compares the measured consumers of a window with the
+// baseline. measurements are keyed by "stream/consumer".
+func (b *Baseline) CompareWindow(win config.WindowConfig, measurements map[string]monitor.ThroughputMeasurement, threshold float64) WindowComparison {
+	wc := WindowComparison{Name: win.Name}
+	var base, current Rate
+	seen := make(map[string]bool)
+	for _, ref := range win.Consumers {
+		key := ref.Key()
+		m, ok := measurements[key]
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		c, ok := b.Compare(ref, m, threshold)
+		if !ok {
+			continue
+		}
+		wc.Consumers = append(wc.Consumers, c)
+		base.DeliveredRate += c.Delivered.Baseline
+		base.AckedRate += c.Acked.Baseline
+		current.DeliveredRate += c.Delivered.Current
+		current.AckedRate += c.Acked.Current
+	}
+	wc.Total = Comparison{
+		Delivered: newChange(base.DeliveredRate, current.DeliveredRate, threshold),
+		Acked:     newChange(base.AckedRate, current.AckedRate, threshold),
+	}
+	return wc
+}
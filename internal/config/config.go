@@ -5,27 +5,465 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
 
+const (
+	// DefaultPollInterval is used when no poll interval is configured.
+	DefaultPollInterval = 1 * time.Second
+
+	defaultIdleAfter       = 30 * time.Second
+	defaultMaxPollInterval = 30 * time.Second
+	defaultBulkThreshold   = 8
+	defaultMaxConcurrent   = 8
+
+	defaultRedeliveryWindow   = 1 * time.Minute
+	defaultGroupWait          = 10 * time.Second
+	defaultRepeatInterval     = 1 * time.Hour
+	defaultAlertmanagerRepeat = 1 * time.Minute // Alertmanager resolves alerts that aren't resent
+	defaultWebhookRetries     = 5
+	defaultWebhookTimeout     = 10 * time.Second
+	defaultHookTimeout        = 30 * time.Second
+	defaultHookConcurrency    = 4
+	defaultTerminalRateLimit  = 1 * time.Minute
+
+	defaultBenchDuration       = 60 * time.Second
+	defaultRegressionThreshold = 10.0
+
+	defaultMinCellWidth  = 40 // Columns, borders included
+	defaultMinCellHeight = 9  // Rows: the state lines and the borders
+)
+
 // ConsumerRef identifies a NATS JetStream consumer to monitor.
 type ConsumerRef struct {
 	Stream   string `json:"stream"`
 	Consumer string `json:"consumer"`
 }
 
+// Key returns the "stream/consumer" key used to index per-consumer state.
+func (r ConsumerRef) Key() string {
+	return r.Stream + "/" + r.Consumer
+}
+
 // WindowConfig defines a window with its layout and consumers.
 type WindowConfig struct {
-	Name      string        `json:"name"`
-	Columns   int           `json:"columns"`
-	Consumers []ConsumerRef `json:"consumers"`
+	Name          string        `json:"name"`
+	Columns       int           `json:"columns"`
+	PollInterval  Duration      `json:"poll_interval,omitempty"`   // Overrides the global interval for this window
+	Checks        *Thresholds   `json:"checks,omitempty"`          // Overrides the global check thresholds for this window
+	View          string        `json:"view,omitempty"`            // "grid" (default), "table" or "compact"
+	MinCellWidth  int           `json:"min_cell_width,omitempty"`  // Narrowest readable grid cell; fewer columns are used below it
+	MinCellHeight int           `json:"min_cell_height,omitempty"` // Lowest readable grid cell; the grid pages below it
+	Template      string        `json:"template,omitempty"`        // text/template for the grid cells; default the global one
+	TemplateFile  string        `json:"template_file,omitempty"`   // File holding the template, relative to the config file
+	Consumers     []ConsumerRef `json:"consumers"`
+}
+
+// Window view modes.
+const (
+	ViewGrid    = "grid"    // One boxed cell per consumer
+	ViewTable   = "table"   // One sortable table row per consumer
+	ViewCompact = "compact" // One line per consumer in dense columns
+)
+
+// Views lists the window view modes in the order the TUI cycles them.
+var Views = []string{ViewGrid, ViewTable, ViewCompact}
+
+// applyDefaults validates the window settings and fills in unset fields.
+func (w *WindowConfig) applyDefaults() error {
+	if w.Columns <= 0 {
+		w.Columns = 4
+	}
+	if w.MinCellWidth <= 0 {
+		w.MinCellWidth = defaultMinCellWidth
+	}
+	if w.MinCellHeight <= 0 {
+		w.MinCellHeight = defaultMinCellHeight
+	}
+	switch w.View {
+	case "":
+		w.View = ViewGrid
+	case ViewGrid, ViewTable, ViewCompact:
+	default:
+		return fmt.Errorf("window %q: unknown view %q (want %s)", w.Name, w.View, strings.Join(Views, ", "))
+	}
+	return nil
+}
+
+// PollConfig controls how often consumers are polled.
+type PollConfig struct {
+	Interval      Duration           `json:"interval"`
+	Adaptive      AdaptivePollConfig `json:"adaptive"`
+	BulkThreshold int                `json:"bulk_threshold"` // Due consumers on one stream before switching to the list API
+	MaxConcurrent int                `json:"max_concurrent"` // Maximum in-flight API requests per poll cycle
+}
+
+// AdaptivePollConfig slows polling down for consumers that have not changed
+// for a while. A consumer that changes again is polled at its base interval.
+type AdaptivePollConfig struct {
+	Enabled     bool     `json:"enabled"`
+	IdleAfter   Duration `json:"idle_after"`   // How long a consumer must be unchanged before backing off
+	MaxInterval Duration `json:"max_interval"` // Upper bound for the backed-off interval
+}
+
+// Thresholds sets the warning and critical levels used by health checks.
+// A zero level is disabled.
+type Thresholds struct {
+	Pending     Limit         `json:"pending"`
+	AckPending  Limit         `json:"ack_pending"`
+	Redelivered Limit         `json:"redelivered"`
+	LastAckAge  DurationLimit `json:"last_ack_age"` // Only checked while messages are outstanding
+}
+
+// Limit is a pair of warning and critical levels for a counter.
+type Limit struct {
+	Warning  uint64 `json:"warning"`
+	Critical uint64 `json:"critical"`
+}
+
+// DurationLimit is a pair of warning and critical levels for an age.
+type DurationLimit struct {
+	Warning  Duration `json:"warning"`
+	Critical Duration `json:"critical"`
+}
+
+// AlertConfig defines when consumers alert and where notifications go.
+// A zero threshold disables its condition; errors always alert.
+type AlertConfig struct {
+	StallAfter      Duration        `json:"stall_after"` // No ack progress for this long while messages are outstanding
+	Backlog         uint64          `json:"backlog"`     // Unprocessed messages at or above this level
+	RedeliverySpike RedeliverySpike `json:"redelivery_spike"`
+	Webhooks        []WebhookConfig `json:"webhooks"`
+	Hooks           []HookConfig    `json:"hooks"`
+	HookConcurrency int             `json:"hook_concurrency"` // Maximum hooks running at once
+	Terminal        TerminalConfig  `json:"terminal"`
+}
+
+// RedeliverySpike alerts when redelivered messages grow by Count within Window.
+type RedeliverySpike struct {
+	Count  int      `json:"count"`
+	Window Duration `json:"window"`
+}
+
+// WebhookConfig is an HTTP endpoint that receives alert notifications.
+type WebhookConfig struct {
+	URL            string            `json:"url"`
+	Format         string            `json:"format"` // "json" (default) or "alertmanager"
+	Headers        map[string]string `json:"headers"`
+	GroupBy        []string          `json:"group_by"`        // Any of "stream", "consumer", "kind"; empty groups everything
+	GroupWait      Duration          `json:"group_wait"`      // How long to batch alerts of a group before sending
+	RepeatInterval Duration          `json:"repeat_interval"` // How often to resend firing alerts
+	SendResolved   *bool             `json:"send_resolved"`   // Defaults to true
+	MaxRetries     *int              `json:"max_retries"`     // Defaults to 5; 0 disables retries
+	Timeout        Duration          `json:"timeout"`
+}
+
+// HookConfig is a shell command run when a consumer enters or leaves an
+// alerting condition.
+type HookConfig struct {
+	Command string   `json:"command"` // Run with /bin/sh -c
+	Kinds   []string `json:"kinds"`   // Alert kinds to run for; empty means all
+	On      []string `json:"on"`      // "firing", "resolved" or both; empty means both
+	Timeout Duration `json:"timeout"`
+}
+
+// TerminalConfig selects how the TUI draws attention to firing alerts.
+type TerminalConfig struct {
+	Methods   []string `json:"methods"`    // Any of "bell", "osc9", "osc777", "tmux"; empty disables notifications
+	Kinds     []string `json:"kinds"`      // Alert kinds to notify for; empty means all
+	RateLimit Duration `json:"rate_limit"` // Minimum time between notifications for the same consumer
+}
+
+// Terminal notification methods.
+const (
+	TerminalBell   = "bell"
+	TerminalOSC9   = "osc9"
+	TerminalOSC777 = "osc777"
+	TerminalTmux   = "tmux"
+)
+
+// Webhook formats.
+const (
+	WebhookFormatJSON         = "json"
+	WebhookFormatAlertmanager = "alertmanager"
+)
+
+// applyDefaults validates the webhook and fills in unset fields.
+func (w *WebhookConfig) applyDefaults() error {
+	if w.URL == "" {
+		return fmt.Errorf("webhook without url")
+	}
+	switch w.Format {
+	case "":
+		w.Format = WebhookFormatJSON
+	case WebhookFormatJSON, WebhookFormatAlertmanager:
+	default:
+		return fmt.Errorf("webhook %s: unknown format %q", w.URL, w.Format)
+	}
+	for _, label := range w.GroupBy {
+		switch label {
+		case "stream", "consumer", "kind":
+		default:
+			return fmt.Errorf("webhook %s: cannot group by %q", w.URL, label)
+		}
+	}
+	if w.GroupWait <= 0 {
+		w.GroupWait = Duration(defaultGroupWait)
+	}
+	if w.RepeatInterval <= 0 {
+		w.RepeatInterval = Duration(defaultRepeatInterval)
+		if w.Format == WebhookFormatAlertmanager {
+			w.RepeatInterval = Duration(defaultAlertmanagerRepeat)
+		}
+	}
+	if w.SendResolved == nil {
+		sendResolved := true
+		w.SendResolved = &sendResolved
+	}
+	switch {
+	case w.MaxRetries == nil:
+		maxRetries := defaultWebhookRetries
+		w.MaxRetries = &maxRetries
+	case *w.MaxRetries < 0:
+		return fmt.Errorf("webhook %s: negative max_retries %d", w.URL, *w.MaxRetries)
+	}
+	if w.Timeout <= 0 {
+		w.Timeout = Duration(defaultWebhookTimeout)
+	}
+	return nil
+}
+
+// applyDefaults validates the hook and fills in unset fields.
+func (h *HookConfig) applyDefaults() error {
+	if h.Command == "" {
+		return fmt.Errorf("hook without command")
+	}
+	for _, kind := range h.Kinds {
+		switch kind {
+		case "error", "stalled", "backlog", "redelivery":
+		default:
+			return fmt.Errorf("hook %q: unknown alert kind %q", h.Command, kind)
+		}
+	}
+	for _, on := range h.On {
+		if on != "firing" && on != "resolved" {
+			return fmt.Errorf("hook %q: unknown transition %q", h.Command, on)
+		}
+	}
+	if h.Timeout <= 0 {
+		h.Timeout = Duration(defaultHookTimeout)
+	}
+	return nil
+}
+
+// applyDefaults validates the terminal notifications and fills in unset
+// fields.
+func (t *TerminalConfig) applyDefaults() error {
+	for _, m := range t.Methods {
+		switch m {
+		case TerminalBell, TerminalOSC9, TerminalOSC777, TerminalTmux:
+		default:
+			return fmt.Errorf("terminal notifications: unknown method %q", m)
+		}
+	}
+	for _, kind := range t.Kinds {
+		switch kind {
+		case "error", "stalled", "backlog", "redelivery":
+		default:
+			return fmt.Errorf("terminal notifications: unknown alert kind %q", kind)
+		}
+	}
+	if t.RateLimit <= 0 {
+		t.RateLimit = Duration(defaultTerminalRateLimit)
+	}
+	return nil
 }
 
 // Config holds the application configuration.
 type Config struct {
-	Consumers []ConsumerRef  // Legacy: flat list of all consumers
-	Windows   []WindowConfig // New: window-based layout
+	Consumers  []ConsumerRef  // Legacy: flat list of all consumers
+	Windows    []WindowConfig // New: window-based layout
+	Poll       PollConfig
+	Checks     Thresholds
+	Alerts     AlertConfig
+	Throughput ThroughputConfig
+	Template   string // Cell template of windows without their own; "" for the built-in one
+	Theme      ThemeConfig
+	Keys       map[string]KeyList // Key bindings by action, replacing the defaults of those actions
+}
+
+// KeyList lists the keys bound to an action, such as "t", "PgUp" or
+// "Ctrl-R".
+type KeyList []string
+
+// UnmarshalJSON accepts either a list of keys or a single key.
+func (k *KeyList) UnmarshalJSON(data []byte) error {
+	var key string
+	if err := json.Unmarshal(data, &key); err == nil {
+		*k = KeyList{key}
+		return nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("invalid keys %s, want a key or a list of keys", data)
+	}
+	*k = keys
+	return nil
+}
+
+// Theme presets.
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeColorblind   = "colorblind"
+)
+
+// ThemePresets lists the built-in themes.
+var ThemePresets = []string{ThemeDark, ThemeLight, ThemeHighContrast, ThemeColorblind}
+
+// Terminal palettes.
+const (
+	PaletteAuto      = "auto"      // Detect from $TERM and $COLORTERM
+	PaletteTrueColor = "truecolor" // 24-bit colors
+	Palette256       = "256"
+	Palette16        = "16"
+	PaletteNone      = "none" // Monochrome, as with $NO_COLOR
+)
+
+// ThemeConfig selects the colors of the terminal UI.
+type ThemeConfig struct {
+	Preset  string            `json:"preset"`  // One of ThemePresets; default dark
+	Colors  map[string]string `json:"colors"`  // Overrides by role, as color names or "#rrggbb"
+	Palette string            `json:"palette"` // Colors the terminal supports; default auto
+}
+
+// applyDefaults validates the theme settings and fills in unset fields.
+// Color roles and values are checked by the UI.
+func (t *ThemeConfig) applyDefaults() error {
+	if t.Preset == "" {
+		t.Preset = ThemeDark
+	}
+	if !slices.Contains(ThemePresets, t.Preset) {
+		return fmt.Errorf("theme: unknown preset %q (want %s)", t.Preset, strings.Join(ThemePresets, ", "))
+	}
+	switch t.Palette {
+	case "":
+		t.Palette = PaletteAuto
+	case PaletteAuto, PaletteTrueColor, Palette256, Palette16, PaletteNone:
+	default:
+		return fmt.Errorf("theme: unknown palette %q", t.Palette)
+	}
+	return nil
+}
+
+// ThroughputConfig holds the throughput measurement settings.
+type ThroughputConfig struct {
+	Duration     Duration `json:"duration"`      // Length of timed measurements and benchmarks
+	ExportFormat string   `json:"export_format"` // "csv" (default), "json" or "markdown"
+	ExportDir    string   `json:"export_dir"`    // Where the TUI writes exports; default the working directory
+
+	BaselineFile        string  `json:"baseline_file"`        // Saved baselines; default $XDG_STATE_HOME/nmonitor/baselines.json
+	RegressionThreshold float64 `json:"regression_threshold"` // Rate drop in percent flagged as a regression
+}
+
+// applyDefaults validates the throughput settings and fills in unset fields.
+func (t *ThroughputConfig) applyDefaults() error {
+	if t.Duration <= 0 {
+		t.Duration = Duration(defaultBenchDuration)
+	}
+	switch t.ExportFormat {
+	case "":
+		t.ExportFormat = "csv"
+	case "csv", "json", "markdown":
+	default:
+		return fmt.Errorf("throughput: unknown export format %q", t.ExportFormat)
+	}
+	if t.ExportDir == "" {
+		t.ExportDir = "."
+	}
+	if t.RegressionThreshold <= 0 {
+		t.RegressionThreshold = defaultRegressionThreshold
+	}
+	return nil
+}
+
+// Duration is a time.Duration that is written in JSON as a Go duration
+// string such as "500ms" or "5s".
+type Duration time.Duration
+
+// Duration returns d as a time.Duration.
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts either a duration string or a number of seconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+
+	var secs float64
+	if err := json.Unmarshal(data, &secs); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(secs * float64(time.Second))
+	return nil
+}
+
+// ConsumerIntervals returns the base poll interval of every configured
+// consumer. A consumer shown in several windows uses the fastest interval.
+func (c *Config) ConsumerIntervals() map[ConsumerRef]time.Duration {
+	intervals := make(map[ConsumerRef]time.Duration)
+	for _, w := range c.Windows {
+		interval := c.Poll.Interval.Duration()
+		if w.PollInterval > 0 {
+			interval = w.PollInterval.Duration()
+		}
+		for _, ref := range w.Consumers {
+			if cur, ok := intervals[ref]; !ok || interval < cur {
+				intervals[ref] = interval
+			}
+		}
+	}
+	for _, ref := range c.Consumers {
+		if _, ok := intervals[ref]; !ok {
+			intervals[ref] = c.Poll.Interval.Duration()
+		}
+	}
+	return intervals
+}
+
+// fileConfig is the on-disk layout of the consumers configuration.
+type fileConfig struct {
+	Windows    []WindowConfig   `json:"windows"`
+	Consumers  []ConsumerRef    `json:"consumers"`
+	Poll       PollConfig       `json:"poll"`
+	Checks     Thresholds       `json:"checks"`
+	Throughput ThroughputConfig `json:"throughput"`
+	Alerts     AlertConfig      `json:"alerts"`
+
+	Template     string             `json:"template"`
+	TemplateFile string             `json:"template_file"`
+	Theme        ThemeConfig        `json:"theme"`
+	Keys         map[string]KeyList `json:"keys"`
 }
 
 // Load reads the consumer configuration from the given path.
@@ -35,56 +473,159 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("read consumers config %s: %w", path, err)
 	}
 
-	// Try parsing as object with "windows" key (new format)
-	var cfgWithWindows struct {
-		Windows []WindowConfig `json:"windows"`
+	var file fileConfig
+	if err := json.Unmarshal(data, &file); err != nil {
+		// Fallback: try parsing as plain array
+		var list []ConsumerRef
+		if errList := json.Unmarshal(data, &list); errList != nil {
+			return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+		}
+		file = fileConfig{Consumers: list}
 	}
-	if err := json.Unmarshal(data, &cfgWithWindows); err == nil && len(cfgWithWindows.Windows) > 0 {
-		// Collect all consumers from all windows
+
+	var cfg *Config
+	if len(file.Windows) > 0 {
+		// New format: collect all consumers from all windows
 		var allConsumers []ConsumerRef
-		for _, w := range cfgWithWindows.Windows {
+		for _, w := range file.Windows {
 			allConsumers = append(allConsumers, w.Consumers...)
 		}
-		// Set default columns if not specified
-		for i := range cfgWithWindows.Windows {
-			if cfgWithWindows.Windows[i].Columns <= 0 {
-				cfgWithWindows.Windows[i].Columns = 4
+		for i := range file.Windows {
+			if err := file.Windows[i].applyDefaults(); err != nil {
+				return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
 			}
 		}
-		return &Config{
+		cfg = &Config{
 			Consumers: allConsumers,
-			Windows:   cfgWithWindows.Windows,
-		}, nil
+			Windows:   file.Windows,
+		}
+	} else {
+		if len(file.Consumers) == 0 {
+			return nil, fmt.Errorf("no consumers configured in %s", path)
+		}
+
+		// Legacy format: create a single default window
+		win := WindowConfig{Name: "Consumers", Consumers: file.Consumers}
+		if err := win.applyDefaults(); err != nil {
+			return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+		}
+		cfg = &Config{
+			Consumers: file.Consumers,
+			Windows:   []WindowConfig{win},
+		}
 	}
 
-	// Try parsing as object with "consumers" key (legacy format)
-	var cfg struct {
-		Consumers []ConsumerRef `json:"consumers"`
+	cfg.Poll = file.Poll
+	cfg.Checks = file.Checks
+	cfg.Alerts = file.Alerts
+	cfg.Throughput = file.Throughput
+	cfg.Theme = file.Theme
+	cfg.Keys = file.Keys
+	if cfg.Poll.Interval <= 0 {
+		cfg.Poll.Interval = Duration(DefaultPollInterval)
 	}
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		// Fallback: try parsing as plain array
-		var list []ConsumerRef
-		if errList := json.Unmarshal(data, &list); errList != nil {
+	if cfg.Poll.Adaptive.IdleAfter <= 0 {
+		cfg.Poll.Adaptive.IdleAfter = Duration(defaultIdleAfter)
+	}
+	if cfg.Poll.Adaptive.MaxInterval <= 0 {
+		cfg.Poll.Adaptive.MaxInterval = Duration(defaultMaxPollInterval)
+	}
+	if cfg.Poll.BulkThreshold <= 0 {
+		cfg.Poll.BulkThreshold = defaultBulkThreshold
+	}
+	if cfg.Poll.MaxConcurrent <= 0 {
+		cfg.Poll.MaxConcurrent = defaultMaxConcurrent
+	}
+	if cfg.Alerts.RedeliverySpike.Window <= 0 {
+		cfg.Alerts.RedeliverySpike.Window = Duration(defaultRedeliveryWindow)
+	}
+	for i := range cfg.Alerts.Webhooks {
+		if err := cfg.Alerts.Webhooks[i].applyDefaults(); err != nil {
+			return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+		}
+	}
+	for i := range cfg.Alerts.Hooks {
+		if err := cfg.Alerts.Hooks[i].applyDefaults(); err != nil {
 			return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
 		}
-		cfg.Consumers = list
+	}
+	if cfg.Alerts.HookConcurrency <= 0 {
+		cfg.Alerts.HookConcurrency = defaultHookConcurrency
+	}
+	if err := cfg.Alerts.Terminal.applyDefaults(); err != nil {
+		return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+	}
+	if err := cfg.Throughput.applyDefaults(); err != nil {
+		return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+	}
+	if err := cfg.Theme.applyDefaults(); err != nil {
+		return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
 	}
 
-	if len(cfg.Consumers) == 0 {
-		return nil, fmt.Errorf("no consumers configured in %s", path)
+	// Windows without a template of their own use the global one
+	dir := filepath.Dir(path)
+	if cfg.Template, err = loadTemplate(dir, file.Template, file.TemplateFile); err != nil {
+		return nil, fmt.Errorf("parse consumers config %s: %w", path, err)
+	}
+	for i := range cfg.Windows {
+		w := &cfg.Windows[i]
+		if w.Template, err = loadTemplate(dir, w.Template, w.TemplateFile); err != nil {
+			return nil, fmt.Errorf("parse consumers config %s: window %q: %w", path, w.Name, err)
+		}
+		if w.Template == "" {
+			w.Template = cfg.Template
+		}
 	}
 
-	// Legacy format: create a single default window
-	return &Config{
-		Consumers: cfg.Consumers,
-		Windows: []WindowConfig{
-			{
-				Name:      "Consumers",
-				Columns:   4,
-				Consumers: cfg.Consumers,
-			},
-		},
-	}, nil
+	return cfg, nil
+}
+
+// loadTemplate returns a cell template given inline or as a file relative
+// to dir.
+func loadTemplate(dir, text, file string) (string, error) {
+	if file == "" {
+		return text, nil
+	}
+	if text != "" {
+		return "", fmt.Errorf("set either template or template_file, not both")
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("read template: %w", err)
+	}
+	return string(data), nil
+}
+
+// ConsumerThresholds returns the check thresholds of a consumer: those of
+// the first window that lists it with its own thresholds, or the global ones.
+func (c *Config) ConsumerThresholds(ref ConsumerRef) Thresholds {
+	for _, w := range c.Windows {
+		if w.Checks == nil {
+			continue
+		}
+		for _, r := range w.Consumers {
+			if r == ref {
+				return *w.Checks
+			}
+		}
+	}
+	return c.Checks
+}
+
+// WindowIndex resolves a window by name or 1-based number.
+func (c *Config) WindowIndex(name string) (int, error) {
+	for i, w := range c.Windows {
+		if w.Name == name {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && n >= 1 && n <= len(c.Windows) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("no window named %q", name)
 }
 
 // natsContext represents the NATS CLI context file format.
@@ -102,29 +643,40 @@ type natsContext struct {
 	Cert        string   `json:"cert"`
 	Key         string   `json:"key"`
 	CA          string   `json:"ca"`
+	JSDomain    string   `json:"jetstream_domain"`
+	JSAPIPrefix string   `json:"jetstream_api_prefix"`
 }
 
-// LoadNATSFromContext loads NATS connection settings from the NATS CLI context.
-func LoadNATSFromContext() (string, []nats.Option, error) {
-	ctxName := os.Getenv("NATS_CONTEXT")
+// NATSConnection holds the settings of a NATS CLI context.
+type NATSConnection struct {
+	URL     string
+	Options []nats.Option
+
+	// JetStream API to use; at most one is set
+	JetStreamDomain    string
+	JetStreamAPIPrefix string
+}
+
+// LoadNATSContext loads NATS connection settings from the named NATS CLI context.
+func LoadNATSContext(ctxName string) (NATSConnection, error) {
 	if ctxName == "" {
-		return "", nil, fmt.Errorf("NATS_CONTEXT is not set")
+		return NATSConnection{}, fmt.Errorf("no NATS context given")
 	}
 
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return "", nil, fmt.Errorf("resolve home directory: %w", err)
+		return NATSConnection{}, fmt.Errorf("resolve home directory: %w", err)
 	}
 
 	contextPath := filepath.Join(home, ".config", "nats", "context", ctxName+".json")
 	data, err := os.ReadFile(contextPath)
 	if err != nil {
-		return "", nil, fmt.Errorf("read NATS context %s: %w", ctxName, err)
+		return NATSConnection{}, fmt.Errorf("read NATS context %s: %w", ctxName, err)
 	}
 
 	var ctx natsContext
 	if err := json.Unmarshal(data, &ctx); err != nil {
-		return "", nil, fmt.Errorf("parse NATS context %s: %w", ctxName, err)
+		return NATSConnection{}, fmt.Errorf("parse NATS context %s: %w", ctxName, err)
 	}
 
 	natsURL := firstNonEmpty(ctx.URL, ctx.ServerURL)
@@ -132,7 +684,7 @@ func LoadNATSFromContext() (string, []nats.Option, error) {
 		natsURL = ctx.Servers[0]
 	}
 	if natsURL == "" {
-		return "", nil, fmt.Errorf("NATS context %s is missing a server URL", ctxName)
+		return NATSConnection{}, fmt.Errorf("NATS context %s is missing a server URL", ctxName)
 	}
 
 	var opts []nats.Option
@@ -157,7 +709,12 @@ func LoadNATSFromContext() (string, []nats.Option, error) {
 		opts = append(opts, nats.RootCAs(ctx.CA))
 	}
 
-	return natsURL, opts, nil
+	return NATSConnection{
+		URL:                natsURL,
+		Options:            opts,
+		JetStreamDomain:    ctx.JSDomain,
+		JetStreamAPIPrefix: ctx.JSAPIPrefix,
+	}, nil
 }
 
 func firstNonEmpty(values ...string) string {
@@ -0,0 +1,244 @@
+// Package export writes throughput measurements as CSV, JSON or Markdown
+// for test reports.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// Export formats.
+const (
+	FormatCSV      = "csv"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatCSV, FormatJSON, FormatMarkdown}
+
+// Row is the throughput of one consumer, or the total of a window.
+type Row struct {
+	Stream        string  `json:"stream,omitempty"`
+	Consumer      string  `json:"consumer,omitempty"`
+	Duration      float64 `json:"duration_seconds"`
+	Delivered     uint64  `json:"delivered"`
+	Acked         uint64  `json:"acked"`
+	DeliveredRate float64 `json:"delivered_rate"`
+	AckedRate     float64 `json:"acked_rate"`
+
+	DeliveredStats *Distribution `json:"delivered_stats,omitempty"` // Only for consumers
+	AckedStats     *Distribution `json:"acked_stats,omitempty"`
+}
+
+// Distribution is how a rate varied over a measurement.
+type Distribution struct {
+	Samples    int     `json:"samples"`
+	Min        float64 `json:"min"`
+	P50        float64 `json:"p50"`
+	P95        float64 `json:"p95"`
+	Max        float64 `json:"max"`
+	StdDev     float64 `json:"stddev"`
+	LongestGap float64 `json:"longest_gap_seconds"` // Longest stretch without progress
+}
+
+// NewDistribution converts rate statistics, returning nil if the
+// measurement has no samples yet.
+func NewDistribution(s monitor.RateStats) *Distribution {
+	if s.Samples == 0 {
+		return nil
+	}
+	return &Distribution{
+		Samples:    s.Samples,
+		Min:        s.Min,
+		P50:        s.P50,
+		P95:        s.P95,
+		Max:        s.Max,
+		StdDev:     s.StdDev,
+		LongestGap: s.LongestGap.Seconds(),
+	}
+}
+
+// Window is the throughput of the consumers of a window. The total sums
+// counts and rates over the consumers and takes the longest duration.
+type Window struct {
+	Name      string `json:"name"`
+	Consumers []Row  `json:"consumers"`
+	Total     Row    `json:"total"`
+}
+
+// Report is a throughput export.
+type Report struct {
+	Time    time.Time `json:"time"`
+	Windows []Window  `json:"windows"`
+}
+
+// NewWindow builds the export of a window from the measurements of a
+// tracker, keyed by "stream/consumer". Consumers without a measurement are
+// left out, and a consumer listed twice is exported once.
+func NewWindow(win config.WindowConfig, measurements map[string]monitor.ThroughputMeasurement) Window {
+	w := Window{Name: win.Name, Consumers: []Row{}}
+	seen := make(map[string]bool)
+	for _, ref := range win.Consumers {
+		key := ref.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		m, ok := measurements[key]
+		if !ok {
+			continue
+		}
+		row := Row{
+			Stream:        ref.Stream,
+			Consumer:      ref.Consumer,
+			Duration:      m.Duration().Seconds(),
+			Delivered:     m.DeliveredCount(),
+			Acked:         m.AckedCount(),
+			DeliveredRate: m.DeliveredRate(),
+			AckedRate:     m.AckedRate(),
+
+			DeliveredStats: NewDistribution(m.DeliveredStats()),
+			AckedStats:     NewDistribution(m.AckedStats()),
+		}
+		w.Consumers = append(w.Consumers, row)
+
+		w.Total.Duration = max(w.Total.Duration, row.Duration)
+		w.Total.Delivered += row.Delivered
+		w.Total.Acked += row.Acked
+		w.Total.DeliveredRate += row.DeliveredRate
+		w.Total.AckedRate += row.AckedRate
+	}
+	return w
+}
+
+// Len returns the number of consumer rows in the report.
+func (r Report) Len() int {
+	n := 0
+	for _, w := range r.Windows {
+		n += len(w.Consumers)
+	}
+	return n
+}
+
+// Extension returns the file extension of a format.
+func Extension(format string) string {
+	if format == FormatMarkdown {
+		return "md"
+	}
+	return format
+}
+
+// ContentType returns the MIME type of a format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatJSON:
+		return "application/json"
+	default:
+		return "text/markdown; charset=utf-8"
+	}
+}
+
+// Write writes the report in the given format.
+func Write(w io.Writer, format string, r Report) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, r)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown:
+		return writeMarkdown(w, r)
+	default:
+		return fmt.Errorf("unknown export format %q (want %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+func writeCSV(w io.Writer, r Report) error {
+	cw := csv.NewWriter(w)
+	header := []string{"window", "stream", "consumer", "duration_seconds", "delivered", "acked", "delivered_rate", "acked_rate"}
+	for _, prefix := range []string{"delivered_", "acked_"} {
+		for _, col := range distributionColumns {
+			header = append(header, prefix+col)
+		}
+	}
+	_ = cw.Write(header)
+	for _, win := range r.Windows {
+		for _, row := range win.Consumers {
+			_ = cw.Write(csvRecord(win.Name, row.Stream, row.Consumer, row))
+		}
+		_ = cw.Write(csvRecord(win.Name, "", "TOTAL", win.Total))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// distributionColumns are the CSV columns of a Distribution, each written
+// once for the delivered and once for the acked rate.
+var distributionColumns = []string{"rate_min", "rate_p50", "rate_p95", "rate_max", "rate_stddev", "longest_gap_seconds"}
+
+func csvRecord(window, stream, consumer string, row Row) []string {
+	record := []string{
+		window,
+		stream,
+		consumer,
+		strconv.FormatFloat(row.Duration, 'f', 3, 64),
+		strconv.FormatUint(row.Delivered, 10),
+		strconv.FormatUint(row.Acked, 10),
+		strconv.FormatFloat(row.DeliveredRate, 'f', 2, 64),
+		strconv.FormatFloat(row.AckedRate, 'f', 2, 64),
+	}
+	for _, d := range []*Distribution{row.DeliveredStats, row.AckedStats} {
+		if d == nil {
+			record = append(record, make([]string, len(distributionColumns))...)
+			continue
+		}
+		for _, v := range []float64{d.Min, d.P50, d.P95, d.Max, d.StdDev, d.LongestGap} {
+			record = append(record, strconv.FormatFloat(v, 'f', 2, 64))
+		}
+	}
+	return record
+}
+
+func writeMarkdown(w io.Writer, r Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Throughput %s\n", r.Time.Format(time.RFC3339))
+	for _, win := range r.Windows {
+		fmt.Fprintf(&b, "\n## %s\n\n", win.Name)
+		b.WriteString("| Stream | Consumer | Duration (s) | Delivered | Acked | Delivered/s | Acked/s | Acked/s p50 / p95 / max | σ | Longest stall (s) |\n")
+		b.WriteString("|--------|----------|-------------:|----------:|------:|------------:|--------:|------------------------:|--:|------------------:|\n")
+		for _, row := range win.Consumers {
+			markdownRow(&b, markdownEscape(row.Stream), markdownEscape(row.Consumer), row)
+		}
+		markdownRow(&b, "**Total**", "", win.Total)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownRow(b *strings.Builder, stream, consumer string, row Row) {
+	fmt.Fprintf(b, "| %s | %s | %.1f | %d | %d | %.1f | %.1f |",
+		stream, consumer, row.Duration, row.Delivered, row.Acked, row.DeliveredRate, row.AckedRate)
+	if d := row.AckedStats; d != nil {
+		fmt.Fprintf(b, " %.1f / %.1f / %.1f | %.1f | %.0f |\n", d.P50, d.P95, d.Max, d.StdDev, d.LongestGap)
+	} else {
+		b.WriteString("  |  |  |\n")
+	}
+}
+
+// markdownEscape keeps a name from breaking the table.
+func markdownEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
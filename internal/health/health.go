@@ -0,0 +1,151 @@
+// Package health evaluates consumer states against warning and critical
+// thresholds.
+package health
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// Status is the outcome of a health check. The values match the Nagios
+// plugin exit codes.
+type Status int
+
+const (
+	OK Status = iota
+	Warning
+	Critical
+	Unknown
+)
+
+// String returns the Nagios name of the status.
+func (s Status) String() string {
+	switch s {
+	case OK:
+		return "OK"
+	case Warning:
+		return "WARNING"
+	case Critical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Worse returns the more severe of two statuses. Critical outranks
+// Unknown, so a failing consumer is never hidden by an unknown one.
+func Worse(a, b Status) Status {
+	if severity(b) > severity(a) {
+		return b
+	}
+	return a
+}
+
+func severity(s Status) int {
+	switch s {
+	case Critical:
+		return 3
+	case Unknown:
+		return 2
+	case Warning:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Metric is a measured value with its thresholds, reported as perfdata.
+type Metric struct {
+	Name     string
+	Value    float64
+	Unit     string // "" for counters, "s" for ages
+	Warning  float64
+	Critical float64
+}
+
+// Result is the health of a single consumer.
+type Result struct {
+	Ref      config.ConsumerRef
+	Status   Status
+	Messages []string // Why the status is not OK
+	Metrics  []Metric
+}
+
+// Evaluate checks a consumer state against the given thresholds.
+// A consumer that could not be fetched is critical.
+func Evaluate(state monitor.ConsumerState, th config.Thresholds, now time.Time) Result {
+	res := Result{Ref: state.Ref, Status: OK}
+	if state.Error != nil {
+		res.Status = Critical
+		res.Messages = append(res.Messages, state.Error.Error())
+		return res
+	}
+
+	snap := state.Snapshot
+	res.checkCount("pending", snap.NumPending, th.Pending)
+	res.checkCount("ack_pending", uint64(snap.NumAckPending), th.AckPending)
+	res.checkCount("redelivered", uint64(snap.NumRedelivered), th.Redelivered)
+
+	// The last ack only goes stale if there is work waiting to be acked
+	age := LastAckAge(state, now)
+	if snap.NumPending == 0 && snap.NumAckPending == 0 {
+		age = 0
+	}
+	res.checkAge("last_ack_age", age, th.LastAckAge)
+
+	return res
+}
+
+// LastAckAge returns how long ago the consumer last acknowledged a message.
+// A consumer that never acked is aged from its creation.
+func LastAckAge(state monitor.ConsumerState, now time.Time) time.Duration {
+	if state.Info == nil {
+		return 0
+	}
+	if last := state.Info.AckFloor.Last; last != nil && !last.IsZero() {
+		return now.Sub(*last)
+	}
+	return now.Sub(state.Info.Created)
+}
+
+func (r *Result) checkCount(name string, value uint64, limit config.Limit) {
+	r.Metrics = append(r.Metrics, Metric{
+		Name:     name,
+		Value:    float64(value),
+		Warning:  float64(limit.Warning),
+		Critical: float64(limit.Critical),
+	})
+
+	switch {
+	case limit.Critical > 0 && value >= limit.Critical:
+		r.raise(Critical, fmt.Sprintf("%s %d >= %d", name, value, limit.Critical))
+	case limit.Warning > 0 && value >= limit.Warning:
+		r.raise(Warning, fmt.Sprintf("%s %d >= %d", name, value, limit.Warning))
+	}
+}
+
+func (r *Result) checkAge(name string, age time.Duration, limit config.DurationLimit) {
+	r.Metrics = append(r.Metrics, Metric{
+		Name:     name,
+		Value:    age.Round(time.Millisecond).Seconds(),
+		Unit:     "s",
+		Warning:  limit.Warning.Duration().Seconds(),
+		Critical: limit.Critical.Duration().Seconds(),
+	})
+
+	rounded := age.Round(time.Second)
+	switch {
+	case limit.Critical > 0 && age >= limit.Critical.Duration():
+		r.raise(Critical, fmt.Sprintf("%s %s >= %s", name, rounded, limit.Critical.Duration()))
+	case limit.Warning > 0 && age >= limit.Warning.Duration():
+		r.raise(Warning, fmt.Sprintf("%s %s >= %s", name, rounded, limit.Warning.Duration()))
+	}
+}
+
+func (r *Result) raise(s Status, msg string) {
+	r.Status = Worse(r.Status, s)
+	r.Messages = append(r.Messages, msg)
+}
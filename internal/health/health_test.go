@@ -0,0 +1,258 @@
+package health
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+var (
+	now    = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	orders = config.ConsumerRef{Stream: "ORDERS", Consumer: "worker"}
+)
+
+// state returns a fetched consumer state that last acked ackedAgo before
+// now, or never if ackedAgo is zero, in which case it was created an hour
+// ago.
+func state(snap monitor.Snapshot, ackedAgo time.Duration) monitor.ConsumerState {
+	info := &nats.ConsumerInfo{Created: now.Add(-time.Hour)}
+	if ackedAgo > 0 {
+		last := now.Add(-ackedAgo)
+		info.AckFloor.Last = &last
+	}
+	return monitor.ConsumerState{Ref: orders, Info: info, Snapshot: snap}
+}
+
+func TestEvaluate(t *testing.T) {
+	th := config.Thresholds{
+		Pending:     config.Limit{Warning: 100, Critical: 1000},
+		AckPending:  config.Limit{Warning: 10, Critical: 50},
+		Redelivered: config.Limit{Warning: 5, Critical: 20},
+		LastAckAge: config.DurationLimit{
+			Warning:  config.Duration(time.Minute),
+			Critical: config.Duration(5 * time.Minute),
+		},
+	}
+
+	tests := []struct {
+		name     string
+		state    monitor.ConsumerState
+		th       config.Thresholds
+		status   Status
+		messages []string
+	}{
+		{
+			name:   "healthy",
+			state:  state(monitor.Snapshot{NumPending: 99, NumAckPending: 9, NumRedelivered: 4}, time.Second),
+			th:     th,
+			status: OK,
+		},
+		{
+			name:     "pending warning at the level",
+			state:    state(monitor.Snapshot{NumPending: 100}, time.Second),
+			th:       th,
+			status:   Warning,
+			messages: []string{"pending 100 >= 100"},
+		},
+		{
+			name:     "pending critical",
+			state:    state(monitor.Snapshot{NumPending: 1500}, time.Second),
+			th:       th,
+			status:   Critical,
+			messages: []string{"pending 1500 >= 1000"},
+		},
+		{
+			name:     "ack pending warning",
+			state:    state(monitor.Snapshot{NumAckPending: 10}, time.Second),
+			th:       th,
+			status:   Warning,
+			messages: []string{"ack_pending 10 >= 10"},
+		},
+		{
+			name:     "ack pending critical",
+			state:    state(monitor.Snapshot{NumAckPending: 50}, time.Second),
+			th:       th,
+			status:   Critical,
+			messages: []string{"ack_pending 50 >= 50"},
+		},
+		{
+			name:     "redelivered warning",
+			state:    state(monitor.Snapshot{NumRedelivered: 7}, 0),
+			th:       th,
+			status:   Warning,
+			messages: []string{"redelivered 7 >= 5"},
+		},
+		{
+			name:     "redelivered critical",
+			state:    state(monitor.Snapshot{NumRedelivered: 20}, 0),
+			th:       th,
+			status:   Critical,
+			messages: []string{"redelivered 20 >= 20"},
+		},
+		{
+			name:     "last ack warning while messages are pending",
+			state:    state(monitor.Snapshot{NumPending: 1}, 2*time.Minute),
+			th:       th,
+			status:   Warning,
+			messages: []string{"last_ack_age 2m0s >= 1m0s"},
+		},
+		{
+			name:     "last ack critical while acks are pending",
+			state:    state(monitor.Snapshot{NumAckPending: 1}, 10*time.Minute),
+			th:       th,
+			status:   Critical,
+			messages: []string{"last_ack_age 10m0s >= 5m0s"},
+		},
+		{
+			name:     "never acked is aged from creation",
+			state:    state(monitor.Snapshot{NumPending: 1}, 0),
+			th:       th,
+			status:   Critical,
+			messages: []string{"last_ack_age 1h0m0s >= 5m0s"},
+		},
+		{
+			name:   "old last ack without outstanding messages",
+			state:  state(monitor.Snapshot{}, time.Hour),
+			th:     th,
+			status: OK,
+		},
+		{
+			name:   "zero levels are disabled",
+			state:  state(monitor.Snapshot{NumPending: 1 << 20, NumAckPending: 1000, NumRedelivered: 1000}, time.Hour),
+			th:     config.Thresholds{},
+			status: OK,
+		},
+		{
+			name:     "the worst threshold wins",
+			state:    state(monitor.Snapshot{NumPending: 200, NumRedelivered: 30}, time.Second),
+			th:       th,
+			status:   Critical,
+			messages: []string{"pending 200 >= 100", "redelivered 30 >= 20"},
+		},
+		{
+			name:     "fetch error",
+			state:    monitor.ConsumerState{Ref: orders, Error: errors.New("consumer not found")},
+			th:       th,
+			status:   Critical,
+			messages: []string{"consumer not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Evaluate(tt.state, tt.th, now)
+			if res.Status != tt.status {
+				t.Errorf("status = %s, want %s", res.Status, tt.status)
+			}
+			if !reflect.DeepEqual(res.Messages, tt.messages) {
+				t.Errorf("messages = %q, want %q", res.Messages, tt.messages)
+			}
+			if res.Ref != orders {
+				t.Errorf("ref = %v, want %v", res.Ref, orders)
+			}
+		})
+	}
+}
+
+func TestEvaluateMetrics(t *testing.T) {
+	th := config.Thresholds{
+		Pending:    config.Limit{Warning: 100, Critical: 1000},
+		LastAckAge: config.DurationLimit{Critical: config.Duration(5 * time.Minute)},
+	}
+	res := Evaluate(state(monitor.Snapshot{NumPending: 42, NumAckPending: 3, NumRedelivered: 1}, 1500*time.Millisecond), th, now)
+
+	want := []Metric{
+		{Name: "pending", Value: 42, Warning: 100, Critical: 1000},
+		{Name: "ack_pending", Value: 3},
+		{Name: "redelivered", Value: 1},
+		{Name: "last_ack_age", Value: 1.5, Unit: "s", Critical: 300},
+	}
+	if !reflect.DeepEqual(res.Metrics, want) {
+		t.Errorf("metrics = %+v, want %+v", res.Metrics, want)
+	}
+
+	// A consumer that could not be fetched has nothing to report
+	res = Evaluate(monitor.ConsumerState{Ref: orders, Error: errors.New("timeout")}, th, now)
+	if len(res.Metrics) != 0 {
+		t.Errorf("metrics of a failed fetch = %+v, want none", res.Metrics)
+	}
+}
+
+func TestEvaluateWindowOverrides(t *testing.T) {
+	billing := config.ConsumerRef{Stream: "BILLING", Consumer: "invoicer"}
+	cfg := &config.Config{
+		Checks: config.Thresholds{Pending: config.Limit{Warning: 100, Critical: 1000}},
+		Windows: []config.WindowConfig{
+			{Name: "default", Consumers: []config.ConsumerRef{billing}},
+			{
+				Name:      "strict",
+				Checks:    &config.Thresholds{Pending: config.Limit{Warning: 10, Critical: 50}},
+				Consumers: []config.ConsumerRef{orders},
+			},
+			{
+				Name:      "lenient",
+				Checks:    &config.Thresholds{Pending: config.Limit{Critical: 1 << 20}},
+				Consumers: []config.ConsumerRef{orders, billing},
+			},
+		},
+	}
+
+	tests := []struct {
+		ref    config.ConsumerRef
+		status Status
+	}{
+		{orders, Critical}, // The first window with thresholds of its own wins
+		{billing, OK},      // Windows without thresholds are skipped
+		{config.ConsumerRef{Stream: "OTHER", Consumer: "x"}, Critical}, // Global
+	}
+	for _, tt := range tests {
+		s := state(monitor.Snapshot{NumPending: 1000}, time.Second)
+		s.Ref = tt.ref
+		res := Evaluate(s, cfg.ConsumerThresholds(tt.ref), now)
+		if res.Status != tt.status {
+			t.Errorf("%s: status = %s, want %s", tt.ref.Key(), res.Status, tt.status)
+		}
+	}
+}
+
+func TestWorse(t *testing.T) {
+	tests := []struct {
+		a, b, want Status
+	}{
+		{OK, OK, OK},
+		{OK, Warning, Warning},
+		{Warning, OK, Warning},
+		{Warning, Unknown, Unknown},
+		{Unknown, Warning, Unknown},
+		{Unknown, Critical, Critical},
+		{Critical, Unknown, Critical},
+		{Critical, Warning, Critical},
+	}
+	for _, tt := range tests {
+		if got := Worse(tt.a, tt.b); got != tt.want {
+			t.Errorf("Worse(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestStatusExitCodes(t *testing.T) {
+	// check exits with the status, so the values are the Nagios exit codes
+	for s, want := range map[Status]struct {
+		code int
+		name string
+	}{
+		OK:       {0, "OK"},
+		Warning:  {1, "WARNING"},
+		Critical: {2, "CRITICAL"},
+		Unknown:  {3, "UNKNOWN"},
+	} {
+		if int(s) != want.code || s.String() != want.name {
+			t.Errorf("status %d %s, want %d %s", int(s), s, want.code, want.name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// defaultAPIPrefix is the default JetStream API subject prefix.
+	defaultAPIPrefix = "$JS.API."
+
+	// defaultRequestTimeout matches the nats.go default JetStream request wait.
+	defaultRequestTimeout = 5 * time.Second
+)
+
+// JetStreamOptions selects the JetStream API the poller talks to. The
+// zero value is the account's own API with the nats.go default timeout.
+type JetStreamOptions struct {
+	Domain    string        // JetStream domain, such as "hub"
+	APIPrefix string        // Custom API subject prefix; not with Domain
+	Timeout   time.Duration // API request timeout; zero for the default
+}
+
+// jsOpts returns the options of the nats.go JetStream context.
+func (o JetStreamOptions) jsOpts() ([]nats.JSOpt, error) {
+	if o.Domain != "" && o.APIPrefix != "" {
+		return nil, fmt.Errorf("jetstream domain and api prefix are mutually exclusive")
+	}
+	opts := []nats.JSOpt{nats.MaxWait(o.timeout())}
+	switch {
+	case o.Domain != "":
+		opts = append(opts, nats.Domain(o.Domain))
+	case o.APIPrefix != "":
+		opts = append(opts, nats.APIPrefix(o.APIPrefix))
+	}
+	return opts, nil
+}
+
+// apiPrefix returns the API subject prefix, ending in a dot, the way
+// nats.go derives it.
+func (o JetStreamOptions) apiPrefix() string {
+	switch {
+	case o.Domain != "":
+		return "$JS." + o.Domain + ".API."
+	case o.APIPrefix != "":
+		return strings.TrimSuffix(o.APIPrefix, ".") + "."
+	default:
+		return defaultAPIPrefix
+	}
+}
+
+func (o JetStreamOptions) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return defaultRequestTimeout
+}
+
+// consumerListRequest asks for one page of the consumer list API.
+type consumerListRequest struct {
+	Offset int `json:"offset,omitempty"`
+}
+
+// consumerListResponse is one page of the consumer list API.
+type consumerListResponse struct {
+	Error     *nats.APIError       `json:"error,omitempty"`
+	Total     int                  `json:"total"`
+	Offset    int                  `json:"offset"`
+	Limit     int                  `json:"limit"`
+	Consumers []*nats.ConsumerInfo `json:"consumers"`
+}
+
+// listConsumers fetches the info of every consumer on a stream using the
+// paged consumer list API of js. It returns the consumers keyed by name and
+// the latency of each API request it made.
+func listConsumers(nc *nats.Conn, js JetStreamOptions, stream string) (map[string]*nats.ConsumerInfo, []time.Duration, error) {
+	subject := fmt.Sprintf("%sCONSUMER.LIST.%s", js.apiPrefix(), stream)
+	infos := make(map[string]*nats.ConsumerInfo)
+	var latencies []time.Duration
+	offset := 0
+
+	for {
+		req, err := json.Marshal(consumerListRequest{Offset: offset})
+		if err != nil {
+			return nil, latencies, err
+		}
+
+		start := time.Now()
+		msg, err := nc.Request(subject, req, js.timeout())
+		latencies = append(latencies, time.Since(start))
+		if err != nil {
+			return nil, latencies, fmt.Errorf("list consumers of %s: %w", stream, err)
+		}
+
+		var resp consumerListResponse
+		if err := json.Unmarshal(msg.Data, &resp); err != nil {
+			return nil, latencies, fmt.Errorf("decode consumer list of %s: %w", stream, err)
+		}
+		if resp.Error != nil {
+			return nil, latencies, resp.Error
+		}
+
+		for _, ci := range resp.Consumers {
+			infos[ci.Name] = ci
+		}
+
+		offset += len(resp.Consumers)
+		if len(resp.Consumers) == 0 || offset >= resp.Total {
+			return infos, latencies, nil
+		}
+	}
+}
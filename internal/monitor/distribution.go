@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// RateStats describes how a rate varied over a throughput measurement, so a
+// consumer that stalls periodically looks different from a smooth one with
+// the same average.
+type RateStats struct {
+	Samples    int // Polls that contributed a rate
+	Min        float64
+	P50        float64
+	P95        float64
+	Max        float64
+	StdDev     float64
+	LongestGap time.Duration // Longest stretch of polls without progress
+}
+
+// rateSeries records the per-poll rates of one counter.
+type rateSeries struct {
+	samples    []float64
+	progressAt time.Time // Last poll that showed progress, or the start
+	longestGap time.Duration
+}
+
+func newRateSeries(start time.Time) rateSeries {
+	return rateSeries{progressAt: start}
+}
+
+// add records the rate between the previous poll and the poll at at.
+func (r *rateSeries) add(rate float64, at time.Time) {
+	r.samples = append(r.samples, rate)
+	if rate > 0 {
+		r.progressAt = at
+		return
+	}
+	r.longestGap = max(r.longestGap, at.Sub(r.progressAt))
+}
+
+func (r rateSeries) stats() RateStats {
+	s := RateStats{Samples: len(r.samples), LongestGap: r.longestGap}
+	if len(r.samples) == 0 {
+		return s
+	}
+
+	sorted := slices.Clone(r.samples)
+	slices.Sort(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+	var variance float64
+	for _, v := range sorted {
+		variance += (v - mean) * (v - mean)
+	}
+
+	s.Min = sorted[0]
+	s.P50 = percentile(sorted, 0.50)
+	s.P95 = percentile(sorted, 0.95)
+	s.Max = sorted[len(sorted)-1]
+	s.StdDev = math.Sqrt(variance / float64(len(sorted)))
+	return s
+}
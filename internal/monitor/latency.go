@@ -0,0 +1,47 @@
+package monitor
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// LatencyStats summarizes the API request latencies of a poll cycle.
+type LatencyStats struct {
+	Count int
+	Min   time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	Max   time.Duration
+	Mean  time.Duration
+}
+
+// summarizeLatency computes latency statistics for a set of requests.
+func summarizeLatency(samples []time.Duration) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		P50:   percentile(sorted, 0.50),
+		P95:   percentile(sorted, 0.95),
+		Max:   sorted[len(sorted)-1],
+		Mean:  total / time.Duration(len(sorted)),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted samples.
+func percentile[T cmp.Ordered](sorted []T, q float64) T {
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(idx, 0), len(sorted)-1)]
+}
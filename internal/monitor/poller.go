@@ -17,84 +17,367 @@ type ConsumerState struct {
 	Snapshot Snapshot
 	Changed  bool // True if state changed from previous poll
 	Error    error
+	PolledAt time.Time     // When the consumer was last fetched
+	Interval time.Duration // Current poll interval (grows when adaptive polling backs off)
+	Latency  time.Duration // Duration of the API request that fetched this state
+	Rates    Rates         // Continuous delivered and acked rates
+}
+
+// CycleStats describes the work done by a single poll cycle.
+type CycleStats struct {
+	Time      time.Time
+	Duration  time.Duration // Wall time from the start of the cycle until it was published
+	Polled    int           // Consumers fetched this cycle
+	APICalls  int           // Total JetStream API requests
+	InfoCalls int           // Single-consumer info requests
+	ListCalls int           // Consumer list page requests
+	Errors    int           // Consumers that failed to fetch
+	Latency   LatencyStats  // Per-request latency
+}
+
+// PublishedAt returns when the cycle's update was handed to subscribers.
+func (s CycleStats) PublishedAt() time.Time {
+	return s.Time.Add(s.Duration)
+}
+
+// Update is published to subscribers after each poll cycle.
+type Update struct {
+	States []ConsumerState
+	Stats  CycleStats
+}
+
+// target holds the scheduling state of a single consumer.
+type target struct {
+	ref        config.ConsumerRef
+	next       time.Time
+	interval   time.Duration
+	lastChange time.Time
+	state      ConsumerState
+	sample     rateSample
+}
+
+// job is a unit of work for the poll worker pool: either a single consumer
+// info request or a paged list of all consumers on a stream.
+type job struct {
+	stream  string
+	targets []*target
+	bulk    bool
+}
+
+// jobResult reports the API usage of a job.
+type jobResult struct {
+	infoCalls int
+	listCalls int
+	errors    int
+	latencies []time.Duration
 }
 
 // Poller periodically fetches consumer info from NATS JetStream.
 type Poller struct {
+	nc        *nats.Conn
 	js        nats.JetStreamContext
+	jsOptions JetStreamOptions
 	consumers []config.ConsumerRef
-	interval  time.Duration
+	schedule  Schedule
+	targets   []*target          // one per unique consumer, in configuration order
+	byKey     map[string]*target // keyed by "stream/consumer"
+
+	configured Schedule           // schedule restored by SetInterval(0)
+	intervals  chan time.Duration // interval changes for Run to apply
+
+	mu          sync.RWMutex
+	snapshots   map[string]Snapshot // keyed by "stream/consumer"
+	streamPages map[string]int      // list API pages needed per stream on the last bulk fetch
 
-	mu        sync.RWMutex
-	snapshots map[string]Snapshot // keyed by "stream/consumer"
+	subscribers
 }
 
-// NewPoller creates a new consumer poller.
-func NewPoller(js nats.JetStreamContext, consumers []config.ConsumerRef, interval time.Duration) *Poller {
-	return &Poller{
-		js:        js,
-		consumers: consumers,
-		interval:  interval,
-		snapshots: make(map[string]Snapshot),
+// NewPoller creates a new consumer poller that talks to the JetStream API
+// selected by jsOptions. Consumers listed more than once are only polled
+// once per cycle.
+func NewPoller(nc *nats.Conn, consumers []config.ConsumerRef, schedule Schedule, jsOptions JetStreamOptions) (*Poller, error) {
+	opts, err := jsOptions.jsOpts()
+	if err != nil {
+		return nil, err
+	}
+	js, err := nc.JetStream(opts...)
+	if err != nil {
+		return nil, err
 	}
+
+	byKey := make(map[string]*target)
+	var targets []*target
+	for _, ref := range consumers {
+		key := ref.Key()
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		t := &target{
+			ref:      ref,
+			interval: schedule.baseInterval(key),
+			state:    ConsumerState{Ref: ref},
+		}
+		byKey[key] = t
+		targets = append(targets, t)
+	}
+
+	return &Poller{
+		nc:          nc,
+		js:          js,
+		jsOptions:   jsOptions,
+		consumers:   consumers,
+		schedule:    schedule,
+		configured:  schedule,
+		intervals:   make(chan time.Duration, 1),
+		targets:     targets,
+		byKey:       byKey,
+		snapshots:   make(map[string]Snapshot),
+		streamPages: make(map[string]int),
+	}, nil
 }
 
-// Run starts the polling loop and sends state updates to the channel.
+// Run starts the polling loop and publishes state updates to subscribers.
+// Publishing never blocks, so a slow subscriber cannot delay polling.
 // It blocks until the context is cancelled.
-func (p *Poller) Run(ctx context.Context, updates chan<- []ConsumerState) {
-	ticker := time.NewTicker(p.interval)
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.schedule.tick())
 	defer ticker.Stop()
 
 	// Initial poll
-	p.poll(updates)
+	p.poll(time.Now())
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			p.poll(updates)
+		case now := <-ticker.C:
+			p.poll(now)
+		case d := <-p.intervals:
+			p.setInterval(d, time.Now())
+			ticker.Reset(p.schedule.tick())
+		}
+	}
+}
+
+// SetInterval polls every consumer at d from now on, overriding the
+// intervals of windows, or as configured again if d is zero. It takes
+// effect in Run and is safe to call from any goroutine.
+func (p *Poller) SetInterval(d time.Duration) {
+	for {
+		select {
+		case p.intervals <- d:
+			return
+		case <-p.intervals: // Replace a change Run hasn't applied yet
+		}
+	}
+}
+
+// setInterval applies an interval change. Consumers due later than the new
+// interval are brought forward.
+func (p *Poller) setInterval(d time.Duration, now time.Time) {
+	p.schedule = p.configured
+	if d > 0 {
+		p.schedule.Interval = d
+		p.schedule.Intervals = nil
+	}
+	for _, t := range p.targets {
+		t.interval = p.schedule.baseInterval(t.ref.Key())
+		if next := now.Add(t.interval); next.Before(t.next) {
+			t.next = next
+		}
+	}
+}
+
+// Once polls every consumer immediately, regardless of schedule, and
+// returns the result without publishing it.
+func (p *Poller) Once() Update {
+	return p.collect(time.Now(), p.targets)
+}
+
+// poll fetches every consumer that is due and publishes the state of all
+// consumers.
+func (p *Poller) poll(now time.Time) {
+	// Allow half a tick of slack so ticker jitter doesn't push a consumer
+	// back by a whole tick.
+	deadline := now.Add(p.schedule.tick() / 2)
+	var due []*target
+	for _, t := range p.targets {
+		if !t.next.After(deadline) {
+			due = append(due, t)
 		}
 	}
+	if len(due) == 0 {
+		return
+	}
+
+	p.publish(p.collect(now, due))
 }
 
-func (p *Poller) poll(updates chan<- []ConsumerState) {
+// collect fetches the given consumers and returns the state of all
+// consumers. Consumers that were not fetched keep their previous state.
+func (p *Poller) collect(now time.Time, due []*target) Update {
+	polled := make(map[*target]bool, len(due))
+	for _, t := range due {
+		polled[t] = true
+	}
+
+	stats := p.runJobs(p.plan(due), now)
+	stats.Time = now
+	stats.Polled = len(due)
+
 	states := make([]ConsumerState, len(p.consumers))
-	var wg sync.WaitGroup
+	for i, ref := range p.consumers {
+		t := p.byKey[ref.Key()]
+		state := t.state
+		if !polled[t] {
+			state.Changed = false
+		}
+		states[i] = state
+	}
+
+	stats.Duration = time.Since(now)
+	return Update{States: states, Stats: stats}
+}
 
-	for i, c := range p.consumers {
+// plan groups due consumers into jobs. Streams with enough due consumers
+// are fetched with the paged list API instead of one request per consumer,
+// unless listing the stream would take more pages than there are consumers.
+func (p *Poller) plan(due []*target) []job {
+	var streams []string
+	byStream := make(map[string][]*target)
+	for _, t := range due {
+		if _, ok := byStream[t.ref.Stream]; !ok {
+			streams = append(streams, t.ref.Stream)
+		}
+		byStream[t.ref.Stream] = append(byStream[t.ref.Stream], t)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var jobs []job
+	for _, stream := range streams {
+		targets := byStream[stream]
+		threshold := p.schedule.BulkThreshold
+		if threshold > 0 && len(targets) >= threshold && p.streamPages[stream] < len(targets) {
+			jobs = append(jobs, job{stream: stream, targets: targets, bulk: true})
+			continue
+		}
+		for _, t := range targets {
+			jobs = append(jobs, job{stream: stream, targets: []*target{t}})
+		}
+	}
+	return jobs
+}
+
+// runJobs executes jobs on a bounded worker pool and returns the API usage.
+func (p *Poller) runJobs(jobs []job, now time.Time) CycleStats {
+	workers := min(max(p.schedule.Workers, 1), len(jobs))
+	queue := make(chan job)
+
+	var mu sync.Mutex
+	var stats CycleStats
+	var latencies []time.Duration
+	var wg sync.WaitGroup
+	for range workers {
 		wg.Add(1)
-		go func(idx int, consumer config.ConsumerRef) {
+		go func() {
 			defer wg.Done()
+			for j := range queue {
+				res := p.runJob(j, now)
+				mu.Lock()
+				stats.InfoCalls += res.infoCalls
+				stats.ListCalls += res.listCalls
+				stats.Errors += res.errors
+				latencies = append(latencies, res.latencies...)
+				mu.Unlock()
+			}
+		}()
+	}
 
-			key := consumer.Stream + "/" + consumer.Consumer
-			state := ConsumerState{Ref: consumer}
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
 
-			ci, err := p.js.ConsumerInfo(consumer.Stream, consumer.Consumer)
-			if err != nil {
-				state.Error = err
-				states[idx] = state
-				return
-			}
+	stats.APICalls = stats.InfoCalls + stats.ListCalls
+	stats.Latency = summarizeLatency(latencies)
+	return stats
+}
+
+// runJob performs one job and reports its API usage.
+func (p *Poller) runJob(j job, now time.Time) jobResult {
+	if !j.bulk {
+		t := j.targets[0]
+		start := time.Now()
+		ci, err := p.js.ConsumerInfo(t.ref.Stream, t.ref.Consumer)
+		latency := time.Since(start)
+		p.apply(t, now, ci, err, latency)
+
+		res := jobResult{infoCalls: 1, latencies: []time.Duration{latency}}
+		if err != nil {
+			res.errors = 1
+		}
+		return res
+	}
+
+	infos, pages, err := listConsumers(p.nc, p.jsOptions, j.stream)
+	if err == nil {
+		p.mu.Lock()
+		p.streamPages[j.stream] = len(pages)
+		p.mu.Unlock()
+	}
+
+	var total time.Duration
+	for _, d := range pages {
+		total += d
+	}
+
+	res := jobResult{listCalls: len(pages), latencies: pages}
+	for _, t := range j.targets {
+		ci, cerr := infos[t.ref.Consumer], err
+		if cerr == nil && ci == nil {
+			cerr = nats.ErrConsumerNotFound
+		}
+		if cerr != nil {
+			res.errors++
+		}
+		p.apply(t, now, ci, cerr, total)
+	}
+	return res
+}
 
-			state.Info = ci
-			state.Snapshot = FromConsumerInfo(ci)
+// apply records the result of fetching a consumer and reschedules it.
+func (p *Poller) apply(t *target, now time.Time, ci *nats.ConsumerInfo, err error, latency time.Duration) {
+	key := t.ref.Key()
+	state := ConsumerState{Ref: t.ref, PolledAt: now, Latency: latency}
 
-			p.mu.RLock()
-			prev, hasPrev := p.snapshots[key]
-			p.mu.RUnlock()
+	if err != nil {
+		state.Error = err
+		t.lastChange = now
+	} else {
+		state.Info = ci
+		state.Snapshot = FromConsumerInfo(ci)
+		state.Rates = t.sample.observe(now, state.Snapshot)
 
-			// Only mark as changed if we have a previous snapshot AND it differs
-			state.Changed = hasPrev && !state.Snapshot.Equal(prev)
+		p.mu.RLock()
+		prev, hasPrev := p.snapshots[key]
+		p.mu.RUnlock()
 
-			p.mu.Lock()
-			p.snapshots[key] = state.Snapshot
-			p.mu.Unlock()
+		// Only mark as changed if we have a previous snapshot AND it differs
+		state.Changed = hasPrev && !state.Snapshot.Equal(prev)
+		if state.Changed || !hasPrev {
+			t.lastChange = now
+		}
 
-			states[idx] = state
-		}(i, c)
+		p.mu.Lock()
+		p.snapshots[key] = state.Snapshot
+		p.mu.Unlock()
 	}
 
-	wg.Wait()
-	updates <- states
+	t.interval = p.schedule.next(key, t.interval, now.Sub(t.lastChange))
+	t.next = now.Add(t.interval)
+	state.Interval = t.interval
+	t.state = state
 }
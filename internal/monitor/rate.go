@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"math"
+	"time"
+)
+
+// rateHalfLife is how long it takes a continuous rate to move halfway to a
+// new steady rate.
+const rateHalfLife = 10 * time.Second
+
+// Rates are the continuous message rates of a consumer in messages per
+// second, smoothed with an exponentially weighted moving average over the
+// polls.
+type Rates struct {
+	Delivered float64
+	Acked     float64
+}
+
+// rateSample is the last successful poll used to compute rates.
+type rateSample struct {
+	at       time.Time
+	snapshot Snapshot
+	rates    Rates
+	valid    bool // rates holds at least one interval
+}
+
+// observe folds a new poll into the rates and returns them. The first poll
+// and a counter going backwards (the consumer was recreated) start over.
+func (s *rateSample) observe(now time.Time, snap Snapshot) Rates {
+	prev := *s
+	*s = rateSample{at: now, snapshot: snap}
+	if prev.at.IsZero() || !now.After(prev.at) ||
+		snap.DeliveredConsumer < prev.snapshot.DeliveredConsumer || snap.AckConsumer < prev.snapshot.AckConsumer {
+		return Rates{}
+	}
+
+	dt := now.Sub(prev.at)
+	current := Rates{
+		Delivered: float64(snap.DeliveredConsumer-prev.snapshot.DeliveredConsumer) / dt.Seconds(),
+		Acked:     float64(snap.AckConsumer-prev.snapshot.AckConsumer) / dt.Seconds(),
+	}
+	s.valid = true
+	if !prev.valid {
+		s.rates = current
+		return s.rates
+	}
+
+	// Weight the new interval by its length, so slow adaptive polls count
+	// for more than fast ones
+	alpha := 1 - math.Exp(-dt.Seconds()*math.Ln2/rateHalfLife.Seconds())
+	s.rates = Rates{
+		Delivered: prev.rates.Delivered + alpha*(current.Delivered-prev.rates.Delivered),
+		Acked:     prev.rates.Acked + alpha*(current.Acked-prev.rates.Acked),
+	}
+	return s.rates
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// minTick is the shortest interval the poll loop will wake up at.
+const minTick = 100 * time.Millisecond
+
+// Schedule controls when each consumer is polled and how the requests are
+// batched.
+type Schedule struct {
+	Interval    time.Duration            // Base interval for consumers without an override
+	Intervals   map[string]time.Duration // Per-consumer base intervals keyed by "stream/consumer"
+	Adaptive    bool                     // Back off consumers that stop changing
+	IdleAfter   time.Duration            // How long a consumer must be unchanged before backing off
+	MaxInterval time.Duration            // Upper bound for backed-off intervals
+
+	BulkThreshold int // Due consumers on one stream before they are fetched with the list API
+	Workers       int // Maximum in-flight API requests per poll cycle
+}
+
+// ScheduleFromConfig builds a poll schedule from the loaded configuration.
+func ScheduleFromConfig(cfg *config.Config) Schedule {
+	intervals := make(map[string]time.Duration)
+	for ref, d := range cfg.ConsumerIntervals() {
+		intervals[ref.Key()] = d
+	}
+	return Schedule{
+		Interval:    cfg.Poll.Interval.Duration(),
+		Intervals:   intervals,
+		Adaptive:    cfg.Poll.Adaptive.Enabled,
+		IdleAfter:   cfg.Poll.Adaptive.IdleAfter.Duration(),
+		MaxInterval: cfg.Poll.Adaptive.MaxInterval.Duration(),
+
+		BulkThreshold: cfg.Poll.BulkThreshold,
+		Workers:       cfg.Poll.MaxConcurrent,
+	}
+}
+
+// baseInterval returns the configured interval for a consumer.
+func (s Schedule) baseInterval(key string) time.Duration {
+	if d, ok := s.Intervals[key]; ok && d > 0 {
+		return d
+	}
+	if s.Interval > 0 {
+		return s.Interval
+	}
+	return config.DefaultPollInterval
+}
+
+// tick returns how often the poll loop must wake up to honor every interval.
+func (s Schedule) tick() time.Duration {
+	tick := s.baseInterval("")
+	for _, d := range s.Intervals {
+		if d > 0 && d < tick {
+			tick = d
+		}
+	}
+	return max(tick, minTick)
+}
+
+// next returns the interval to wait before polling a consumer again.
+// Changed consumers snap back to their base interval; idle ones double
+// their interval until MaxInterval is reached.
+func (s Schedule) next(key string, current time.Duration, idle time.Duration) time.Duration {
+	base := s.baseInterval(key)
+	if !s.Adaptive || idle < s.IdleAfter || current < base {
+		return base
+	}
+	return min(max(current*2, base), max(s.MaxInterval, base))
+}
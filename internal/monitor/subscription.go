@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription delivers poll updates to a single reader. Only the latest
+// update is kept: if the reader falls behind, stale updates are dropped
+// instead of blocking the poller.
+type Subscription struct {
+	// C receives the most recent update. It is closed by Close.
+	C <-chan Update
+
+	ch      chan Update
+	poller  *Poller
+	dropped atomic.Uint64
+}
+
+// Dropped returns how many updates were replaced before the reader got them.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops delivery and closes C.
+func (s *Subscription) Close() {
+	s.poller.subMu.Lock()
+	defer s.poller.subMu.Unlock()
+	if _, ok := s.poller.subs[s]; !ok {
+		return
+	}
+	delete(s.poller.subs, s)
+	close(s.ch)
+}
+
+// offer replaces any pending update with u. It never blocks.
+func (s *Subscription) offer(u Update) {
+	select {
+	case s.ch <- u:
+		return
+	default:
+	}
+
+	// Drop the stale update the reader hasn't picked up yet
+	select {
+	case <-s.ch:
+		s.dropped.Add(1)
+	default:
+	}
+
+	select {
+	case s.ch <- u:
+	default:
+	}
+}
+
+// subscribers is embedded in Poller to fan updates out to subscriptions.
+type subscribers struct {
+	subMu sync.Mutex
+	subs  map[*Subscription]struct{}
+}
+
+// Subscribe returns a new subscription to poll updates.
+func (p *Poller) Subscribe() *Subscription {
+	ch := make(chan Update, 1)
+	sub := &Subscription{C: ch, ch: ch, poller: p}
+
+	p.subMu.Lock()
+	defer p.subMu.Unlock()
+	if p.subs == nil {
+		p.subs = make(map[*Subscription]struct{})
+	}
+	p.subs[sub] = struct{}{}
+	return sub
+}
+
+// publish offers an update to every subscription.
+func (p *Poller) publish(u Update) {
+	p.subMu.Lock()
+	defer p.subMu.Unlock()
+	for sub := range p.subs {
+		sub.offer(u)
+	}
+}
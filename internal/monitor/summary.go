@@ -0,0 +1,95 @@
+package monitor
+
+import (
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// Partition is a consumer and its acked rate, used to name the slowest and
+// fastest consumer of a window.
+type Partition struct {
+	Ref       config.ConsumerRef
+	AckedRate float64
+}
+
+// RateTotals sums the delivered and acked rates of a set of consumers.
+type RateTotals struct {
+	Delivered float64
+	Acked     float64
+	Slowest   *Partition // nil with fewer than two consumers
+	Fastest   *Partition
+}
+
+// add counts a consumer into the totals.
+func (t *RateTotals) add(ref config.ConsumerRef, delivered, acked float64) {
+	t.Delivered += delivered
+	t.Acked += acked
+	if t.Slowest == nil || acked < t.Slowest.AckedRate {
+		t.Slowest = &Partition{Ref: ref, AckedRate: acked}
+	}
+	if t.Fastest == nil || acked > t.Fastest.AckedRate {
+		t.Fastest = &Partition{Ref: ref, AckedRate: acked}
+	}
+}
+
+// finish drops the slowest and fastest partition when there is nothing to
+// compare.
+func (t *RateTotals) finish(n int) {
+	if n < 2 {
+		t.Slowest, t.Fastest = nil, nil
+	}
+}
+
+// WindowSummary aggregates the consumers of a window, which usually hold
+// the partitions of one service.
+type WindowSummary struct {
+	Consumers  int // Consumers with a state
+	Errors     int // Consumers that failed to fetch
+	Pending    uint64
+	AckPending int
+	Rates      RateTotals // Continuous rates of the consumers that were fetched
+
+	Measured   int        // Consumers with a throughput measurement
+	Throughput RateTotals // Rates of the throughput measurements
+	Delivered  uint64     // Messages delivered during the measurements
+	Acked      uint64     // Messages acked during the measurements
+}
+
+// Summarize aggregates the states and throughput measurements of refs.
+// states and measurements are keyed by "stream/consumer"; measurements may
+// be nil. Consumers listed twice are counted once.
+func Summarize(refs []config.ConsumerRef, states map[string]ConsumerState, measurements map[string]ThroughputMeasurement) WindowSummary {
+	var s WindowSummary
+	seen := make(map[string]bool)
+	fetched := 0
+	for _, ref := range refs {
+		key := ref.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if m, ok := measurements[key]; ok {
+			s.Measured++
+			s.Delivered += m.DeliveredCount()
+			s.Acked += m.AckedCount()
+			s.Throughput.add(ref, m.DeliveredRate(), m.AckedRate())
+		}
+
+		state, ok := states[key]
+		if !ok {
+			continue
+		}
+		s.Consumers++
+		if state.Error != nil {
+			s.Errors++
+			continue
+		}
+		fetched++
+		s.Pending += state.Snapshot.NumPending
+		s.AckPending += state.Snapshot.NumAckPending
+		s.Rates.add(ref, state.Rates.Delivered, state.Rates.Acked)
+	}
+	s.Rates.finish(fetched)
+	s.Throughput.finish(s.Measured)
+	return s
+}
@@ -5,14 +5,48 @@ import (
 	"time"
 )
 
-// ThroughputMeasurement tracks message throughput for a consumer.
+// ThroughputMeasurement tracks message throughput for a consumer. Its times
+// are those of the polls its counters come from, so the counts and the
+// duration cover the same window.
 type ThroughputMeasurement struct {
-	StartTime        time.Time
-	EndTime          time.Time // Zero if still measuring
+	StartTime        time.Time // Poll time of the start counters
+	EndTime          time.Time // Poll time of the last counters; zero if still measuring
 	StartDelivered   uint64
 	StartAcked       uint64
 	CurrentDelivered uint64
 	CurrentAcked     uint64
+
+	sampledAt time.Time // Poll time of the current counters
+	delivered rateSeries
+	acked     rateSeries
+}
+
+// DeliveredStats returns the distribution of the per-poll delivered rate.
+func (t ThroughputMeasurement) DeliveredStats() RateStats {
+	return t.delivered.stats()
+}
+
+// AckedStats returns the distribution of the per-poll acked rate.
+func (t ThroughputMeasurement) AckedStats() RateStats {
+	return t.acked.stats()
+}
+
+// sample records the rates since the previous poll. A state that was not
+// polled again since is ignored.
+func (t *ThroughputMeasurement) sample(state ConsumerState) {
+	at := state.PolledAt
+	if !at.After(t.sampledAt) {
+		return
+	}
+	snap := state.Snapshot
+	if snap.DeliveredConsumer >= t.CurrentDelivered && snap.AckConsumer >= t.CurrentAcked {
+		dt := at.Sub(t.sampledAt).Seconds()
+		t.delivered.add(float64(snap.DeliveredConsumer-t.CurrentDelivered)/dt, at)
+		t.acked.add(float64(snap.AckConsumer-t.CurrentAcked)/dt, at)
+	}
+	t.sampledAt = at
+	t.CurrentDelivered = snap.DeliveredConsumer
+	t.CurrentAcked = snap.AckConsumer
 }
 
 // DeliveredCount returns the number of messages delivered during measurement.
@@ -25,10 +59,11 @@ func (t ThroughputMeasurement) AckedCount() uint64 {
 	return t.CurrentAcked - t.StartAcked
 }
 
-// Duration returns how long the measurement ran (or is running).
+// Duration returns how long the measurement ran, or while it is running,
+// the time between the polls of the start and current counters.
 func (t ThroughputMeasurement) Duration() time.Duration {
 	if t.EndTime.IsZero() {
-		return time.Since(t.StartTime)
+		return t.sampledAt.Sub(t.StartTime)
 	}
 	return t.EndTime.Sub(t.StartTime)
 }
@@ -55,6 +90,7 @@ func (t ThroughputMeasurement) AckedRate() float64 {
 type ThroughputTracker struct {
 	mu           sync.RWMutex
 	measuring    bool
+	deadline     time.Time                         // When the running measurement stops itself; zero if open-ended
 	measurements map[string]*ThroughputMeasurement // keyed by "stream/consumer"
 }
 
@@ -78,17 +114,64 @@ func (t *ThroughputTracker) Toggle(states []ConsumerState) bool {
 	defer t.mu.Unlock()
 
 	if t.measuring {
-		// Stop measuring - set end time on all measurements
-		t.measuring = false
-		endTime := time.Now()
-		for _, m := range t.measurements {
-			m.EndTime = endTime
-		}
+		t.stop()
+		return false
+	}
+	t.start(states)
+	return true
+}
+
+// Start begins a new measurement, discarding previous results.
+// Returns false if a measurement is already running.
+func (t *ThroughputTracker) Start(states []ConsumerState) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.measuring {
 		return false
 	}
+	t.start(states)
+	return true
+}
 
-	// Start measuring
+// StartFor begins a measurement that stops itself on the first update after
+// d has passed, discarding previous results. Returns false if a measurement
+// is already running.
+func (t *ThroughputTracker) StartFor(states []ConsumerState, d time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.measuring {
+		return false
+	}
+	t.start(states)
+	t.deadline = time.Now().Add(d)
+	return true
+}
+
+// Deadline returns when the running measurement stops itself, or the zero
+// time if it runs until stopped.
+func (t *ThroughputTracker) Deadline() time.Time {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.deadline
+}
+
+// Stop ends the running measurement. Returns false if none was running.
+func (t *ThroughputTracker) Stop() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.measuring {
+		return false
+	}
+	t.stop()
+	return true
+}
+
+func (t *ThroughputTracker) start(states []ConsumerState) {
 	t.measuring = true
+	t.deadline = time.Time{}
 	t.measurements = make(map[string]*ThroughputMeasurement)
 	now := time.Now()
 
@@ -96,38 +179,56 @@ func (t *ThroughputTracker) Toggle(states []ConsumerState) bool {
 		if state.Error != nil {
 			continue
 		}
-		key := state.Ref.Stream + "/" + state.Ref.Consumer
-		t.measurements[key] = &ThroughputMeasurement{
-			StartTime:        now,
+		polledAt := state.PolledAt
+		if polledAt.IsZero() {
+			polledAt = now
+		}
+		t.measurements[state.Ref.Key()] = &ThroughputMeasurement{
+			StartTime:        polledAt,
 			StartDelivered:   state.Snapshot.DeliveredConsumer,
 			StartAcked:       state.Snapshot.AckConsumer,
 			CurrentDelivered: state.Snapshot.DeliveredConsumer,
 			CurrentAcked:     state.Snapshot.AckConsumer,
+			sampledAt:        polledAt,
+			delivered:        newRateSeries(polledAt),
+			acked:            newRateSeries(polledAt),
 		}
 	}
+}
 
-	return true
+// stop ends all measurements at the poll of their last counters.
+func (t *ThroughputTracker) stop() {
+	t.measuring = false
+	t.deadline = time.Time{}
+	for _, m := range t.measurements {
+		m.EndTime = m.sampledAt
+	}
 }
 
-// Update updates current values for all tracked consumers.
-func (t *ThroughputTracker) Update(states []ConsumerState) {
+// Update updates current values for all tracked consumers and stops a
+// timed measurement whose deadline has passed. Returns true if it stopped.
+func (t *ThroughputTracker) Update(states []ConsumerState) bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	if !t.measuring {
-		return
+		return false
 	}
 
 	for _, state := range states {
 		if state.Error != nil {
 			continue
 		}
-		key := state.Ref.Stream + "/" + state.Ref.Consumer
-		if m, ok := t.measurements[key]; ok {
-			m.CurrentDelivered = state.Snapshot.DeliveredConsumer
-			m.CurrentAcked = state.Snapshot.AckConsumer
+		if m, ok := t.measurements[state.Ref.Key()]; ok {
+			m.sample(state)
 		}
 	}
+
+	if !t.deadline.IsZero() && !time.Now().Before(t.deadline) {
+		t.stop()
+		return true
+	}
+	return false
 }
 
 // Get returns the measurement for a consumer, if any.
@@ -144,10 +245,23 @@ func (t *ThroughputTracker) Get(stream, consumer string) *ThroughputMeasurement
 	return nil
 }
 
+// All returns a copy of every measurement keyed by "stream/consumer".
+func (t *ThroughputTracker) All() map[string]ThroughputMeasurement {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	all := make(map[string]ThroughputMeasurement, len(t.measurements))
+	for key, m := range t.measurements {
+		all[key] = *m
+	}
+	return all
+}
+
 // Clear removes all measurements.
 func (t *ThroughputTracker) Clear() {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.measuring = false
+	t.deadline = time.Time{}
 	t.measurements = make(map[string]*ThroughputMeasurement)
 }
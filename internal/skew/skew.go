@@ -0,0 +1,155 @@
+// Package skew finds the consumers of a window that are out of line with
+// their siblings, such as a partition with a hot key or a slow worker.
+//
+// Outliers are found with the modified z-score of Iglewicz and Hoaglin,
+// which compares each value with the window median and scales by the median
+// absolute deviation (MAD). Unlike a mean and standard deviation, a single
+// extreme partition can't hide itself by shifting the baseline, and no
+// per-consumer thresholds are needed.
+package skew
+
+import (
+	"math"
+	"slices"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+const (
+	// OutlierScore is the modified z-score above which a value is an
+	// outlier, as recommended by Iglewicz and Hoaglin.
+	OutlierScore = 3.5
+
+	// madScale makes the MAD comparable to a standard deviation for
+	// normally distributed values.
+	madScale = 0.6745
+	// meanADScale does the same for the mean absolute deviation, which is
+	// used when more than half of the values are equal and the MAD is 0.
+	meanADScale = 0.7979
+)
+
+// Value is the value of one consumer for a metric.
+type Value struct {
+	Ref     config.ConsumerRef
+	Value   float64
+	Score   float64 // Modified z-score; positive above the median
+	Outlier bool
+}
+
+// Metric compares the consumers of a window on one measure.
+type Metric struct {
+	Name   string
+	Unit   string // "" for counts, "/s" for rates
+	Median float64
+	MAD    float64
+	Max    float64
+	Values []Value // In window order
+}
+
+// Outliers returns the number of outliers of the metric.
+func (m Metric) Outliers() int {
+	n := 0
+	for _, v := range m.Values {
+		if v.Outlier {
+			n++
+		}
+	}
+	return n
+}
+
+// metricDef extracts one measure from a consumer state.
+type metricDef struct {
+	name  string
+	unit  string
+	value func(monitor.ConsumerState) float64
+}
+
+var metrics = []metricDef{
+	{"Unprocessed", "", func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumPending) }},
+	{"Outstanding Acks", "", func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumAckPending) }},
+	{"Delivered", "/s", func(s monitor.ConsumerState) float64 { return s.Rates.Delivered }},
+	{"Acked", "/s", func(s monitor.ConsumerState) float64 { return s.Rates.Acked }},
+}
+
+// Analyze compares the consumers of refs that have been fetched. states are
+// keyed by "stream/consumer". Consumers listed twice count once.
+func Analyze(refs []config.ConsumerRef, states map[string]monitor.ConsumerState) []Metric {
+	var fetched []monitor.ConsumerState
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		key := ref.Key()
+		state, ok := states[key]
+		if !ok || seen[key] || state.Error != nil {
+			continue
+		}
+		seen[key] = true
+		fetched = append(fetched, state)
+	}
+
+	result := make([]Metric, len(metrics))
+	for i, def := range metrics {
+		values := make([]float64, len(fetched))
+		for j, state := range fetched {
+			values[j] = def.value(state)
+		}
+
+		m := Metric{Name: def.name, Unit: def.unit}
+		scores := modifiedZ(values, &m.Median, &m.MAD)
+		for j, state := range fetched {
+			m.Max = max(m.Max, values[j])
+			m.Values = append(m.Values, Value{
+				Ref:     state.Ref,
+				Value:   values[j],
+				Score:   scores[j],
+				Outlier: math.Abs(scores[j]) > OutlierScore,
+			})
+		}
+		result[i] = m
+	}
+	return result
+}
+
+// modifiedZ returns the modified z-score of every value and sets the median
+// and MAD. With fewer than three values, or no spread at all, every score
+// is 0.
+func modifiedZ(values []float64, median, mad *float64) []float64 {
+	scores := make([]float64, len(values))
+	if len(values) == 0 {
+		return scores
+	}
+
+	*median = medianOf(values)
+	deviations := make([]float64, len(values))
+	var sumDev float64
+	for i, v := range values {
+		deviations[i] = math.Abs(v - *median)
+		sumDev += deviations[i]
+	}
+	*mad = medianOf(deviations)
+	if len(values) < 3 {
+		return scores
+	}
+
+	scale := *mad / madScale
+	if *mad == 0 {
+		scale = sumDev / float64(len(values)) / meanADScale
+	}
+	if scale == 0 {
+		return scores
+	}
+	for i, v := range values {
+		scores[i] = (v - *median) / scale
+	}
+	return scores
+}
+
+func medianOf(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
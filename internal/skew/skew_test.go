@@ -0,0 +1,82 @@
+package skew
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModifiedZ(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		median float64
+		mad    float64
+		scores []float64
+	}{
+		{
+			name:   "empty",
+			values: nil,
+			scores: []float64{},
+		},
+		{
+			name:   "fewer than three values",
+			values: []float64{1, 100},
+			median: 50.5,
+			mad:    49.5,
+			scores: []float64{0, 0},
+		},
+		{
+			name:   "no spread",
+			values: []float64{7, 7, 7, 7},
+			median: 7,
+			scores: []float64{0, 0, 0, 0},
+		},
+		{
+			name:   "scaled by the MAD",
+			values: []float64{10, 12, 14, 16, 100},
+			median: 14,
+			mad:    2,
+			scores: []float64{-4 * madScale / 2, -2 * madScale / 2, 0, 2 * madScale / 2, 86 * madScale / 2},
+		},
+		{
+			// More than half the values are equal, so the MAD is 0 and the
+			// mean absolute deviation (90/5 = 18) stands in
+			name:   "zero MAD falls back to the mean absolute deviation",
+			values: []float64{5, 5, 5, 5, 95},
+			median: 5,
+			mad:    0,
+			scores: []float64{0, 0, 0, 0, 90 * meanADScale / 18},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var median, mad float64
+			scores := modifiedZ(tt.values, &median, &mad)
+			if median != tt.median || mad != tt.mad {
+				t.Errorf("median, MAD = %v, %v, want %v, %v", median, mad, tt.median, tt.mad)
+			}
+			if len(scores) != len(tt.scores) {
+				t.Fatalf("scores = %v, want %v", scores, tt.scores)
+			}
+			for i := range scores {
+				if math.Abs(scores[i]-tt.scores[i]) > 1e-9 {
+					t.Errorf("scores = %v, want %v", scores, tt.scores)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestModifiedZOutlier(t *testing.T) {
+	// One hot partition among evenly loaded ones crosses the threshold;
+	// the others stay well inside it
+	values := []float64{100, 104, 98, 101, 99, 400}
+	var median, mad float64
+	scores := modifiedZ(values, &median, &mad)
+	for i, score := range scores {
+		if outlier := math.Abs(score) > OutlierScore; outlier != (i == len(values)-1) {
+			t.Errorf("value %v: score %.2f, outlier %v", values[i], score, outlier)
+		}
+	}
+}
@@ -3,109 +3,222 @@ package ui
 import (
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 
+	"github.com/jrlangford/nats-consumer-monitor/internal/alert"
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
 	"github.com/jrlangford/nats-consumer-monitor/internal/config"
 	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
 )
 
 const (
-	flashDuration     = 180 * time.Millisecond
-	windowPageFmt     = "window-%d"
-	defaultStatusText = "[dim]'t' throughput | 'c' clear | '<'/'>' windows | 'q'/Ctrl-C quit | double-click to copy[-]"
+	flashDuration = 180 * time.Millisecond
+	summaryHeight = 2
+	windowPageFmt = "window-%d"
 )
 
 // WindowPanel represents a single window/panel in the UI.
 type WindowPanel struct {
 	config     config.WindowConfig
-	grid       *tview.Grid
+	root       *tview.Flex  // Body above the summary and status bar
+	body       *tview.Pages // Holds the grid and the table
+	grid       *cellGrid
+	empty      *tview.TextView // Replaces the grid cells when none match the filter
+	visible    []int           // Indexes of the consumers shown, nil before the first layout
+	page       int             // Current page of the grid
+	pageCount  int
+	table      *ConsumerTable
+	compact    *compactView
+	template   *template.Template // Renders the grid cells
+	view       string             // One of config.Views
 	views      []*SelectableTextView
 	viewMap    map[string]*SelectableTextView // keyed by "stream/consumer"
+	summary    *tview.TextView
 	statusBar  *tview.TextView
 	throughput *monitor.ThroughputTracker
 	theme      Theme
 	flashC     *FlashController
+	baseline   *atomic.Pointer[baseline.Baseline] // Shared by all panels
+	threshold  float64                            // Regression threshold in percent
 }
 
 // App encapsulates the terminal UI application.
 type App struct {
 	app        *tview.Application
+	root       *tview.Flex // Tab bar above the pages
+	tabs       *tabBar     // nil with a single window
 	pages      *tview.Pages
 	panels     []*WindowPanel
 	theme      Theme
+	keys       *KeyMap
 	currentIdx int
 	lastStates []monitor.ConsumerState
+	debug      *DebugView
+	debugShown bool
+	skew       *SkewView
+	skewShown  bool
+	stats      *monitor.CycleStats    // Latest poll cycle, nil before the first
+	alerts     map[string]alert.Event // Firing alerts by alert key
+	notifier   *terminalNotifier
+	notice     notice
+	throughput config.ThroughputConfig
+	baselines  *baseline.Store
+	baseline   *atomic.Pointer[baseline.Baseline] // Baseline measurements are compared with
+	dialogOpen bool
+
+	filter       consumerFilter
+	filterStates []monitor.ConsumerState // States the filter was last applied to
+	thresholds   func(config.ConsumerRef) config.Thresholds
+	stallAfter   time.Duration
+
+	poller  IntervalSetter // Changed from the command palette
+	history []string       // Commands run from the palette, oldest first
 }
 
-// NewApp creates a new UI application with multiple window panels.
-func NewApp(windows []config.WindowConfig) *App {
-	theme := DefaultTheme()
+// NewApp creates a new UI application with multiple window panels drawn in
+// theme and driven by keys. It fails if the cell template of a window
+// doesn't parse.
+func NewApp(windows []config.WindowConfig, theme Theme, keys *KeyMap) (*App, error) {
+	theme.applyStyles()
 	app := tview.NewApplication()
 
 	panels := make([]*WindowPanel, len(windows))
 	pages := tview.NewPages()
+	current := new(atomic.Pointer[baseline.Baseline])
 
 	for i, win := range windows {
 		panel := newWindowPanel(win, theme)
+		tmpl, err := parseCellTemplate(win.Name, win.Template, theme)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", win.Name, err)
+		}
+		panel.template = tmpl
+		panel.baseline = current
 		panels[i] = panel
-		pages.AddPage(fmt.Sprintf(windowPageFmt, i), panel.grid, true, i == 0)
+		pages.AddPage(fmt.Sprintf(windowPageFmt, i), panel.root, true, i == 0)
+	}
+
+	debug := NewDebugView(theme)
+	debug.view.SetTitle(withHint(" Poll latency ", keys.hint("to close", actionDebug)))
+	pages.AddPage(debugPage, debug.view, true, false)
+	skew := NewSkewView(theme)
+	skew.hint = keys.hint("to close", actionSkew)
+	pages.AddPage(skewPage, skew.view, true, false)
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow)
+	var tabs *tabBar
+	if len(panels) > 1 {
+		tabs = newTabBar(theme)
+		root.AddItem(tabs, 1, 0, false)
 	}
+	root.AddItem(pages, 0, 1, true)
 
-	return &App{
+	a := &App{
 		app:        app,
+		root:       root,
+		tabs:       tabs,
 		pages:      pages,
 		panels:     panels,
 		theme:      theme,
+		keys:       keys,
 		currentIdx: 0,
+		debug:      debug,
+		skew:       skew,
+		alerts:     make(map[string]alert.Event),
+		baseline:   current,
+		stallAfter: defaultStallAfter,
+		notifier:   newTerminalNotifier(config.TerminalConfig{}, os.Stdout),
 	}
+	for _, panel := range panels {
+		panel.compact.status = a.consumerStatus
+		panel.table.hint = joinHints(", ", keys.hint("column", actionSortPrev, actionSortNext), keys.hint("reverse", actionSortReverse))
+		panel.table.Refresh(time.Now())
+		panel.grid.onResize = func() {
+			panel.layoutGrid()
+			a.refreshStatus()
+		}
+	}
+	if tabs != nil {
+		tabs.onSelect = func(idx int) {
+			if !a.dialogOpen {
+				a.SelectWindow(idx)
+			}
+		}
+	}
+	a.refreshStatus()
+	return a, nil
 }
 
 func newWindowPanel(win config.WindowConfig, theme Theme) *WindowPanel {
 	numConsumers := len(win.Consumers)
-	columns := win.Columns
-	if columns <= 0 {
-		columns = 4
-	}
-
-	// Calculate grid rows based on number of consumers (add 1 for status bar)
-	rows := (numConsumers + columns - 1) / columns
-	rowSizes := make([]int, rows+1)
-	for i := range rows {
-		rowSizes[i] = 0 // 0 means equal distribution
-	}
-	rowSizes[rows] = 1 // Status bar row
 
-	colSizes := make([]int, columns)
-	for i := range colSizes {
-		colSizes[i] = 0 // equal distribution
-	}
-
-	grid := tview.NewGrid().
-		SetRows(rowSizes...).
-		SetColumns(colSizes...)
+	// Rows and columns are set by layoutGrid once the views exist
+	grid := newCellGrid()
 	grid.SetBackgroundColor(theme.Background)
 
+	// Shown instead of the cells when the filter hides all of them
+	empty := tview.NewTextView().
+		SetTextAlign(tview.AlignCenter).
+		SetText("No consumers match the filter")
+	empty.SetBackgroundColor(theme.Background)
+	empty.SetTextColor(theme.WarningText)
+
 	views := make([]*SelectableTextView, numConsumers)
 	viewMap := make(map[string]*SelectableTextView)
 
+	table := NewConsumerTable(win.Consumers, theme)
+
+	compact := newCompactView(win.Consumers, theme)
+
+	view := win.View
+	if view == "" {
+		view = config.ViewGrid
+	}
+
+	// The views share the body; the mode picks the visible one
+	body := tview.NewPages().
+		AddPage(config.ViewGrid, grid, true, view == config.ViewGrid).
+		AddPage(config.ViewTable, table.view, true, view == config.ViewTable).
+		AddPage(config.ViewCompact, compact, true, view == config.ViewCompact)
+
+	// Create window summary
+	summary := tview.NewTextView()
+	summary.SetDynamicColors(true)
+	summary.SetBackgroundColor(theme.Background)
+
 	// Create status bar
 	statusBar := tview.NewTextView()
 	statusBar.SetDynamicColors(true)
 	statusBar.SetBackgroundColor(theme.Background)
 	statusBar.SetTextAlign(tview.AlignCenter)
-	statusBar.SetText(defaultStatusText)
-	grid.AddItem(statusBar, rows, 0, 1, columns, 0, 0, false)
+
+	root := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(body, 0, 1, true).
+		AddItem(summary, summaryHeight, 0, false).
+		AddItem(statusBar, 1, 0, false)
 
 	return &WindowPanel{
 		config:     win,
+		root:       root,
+		body:       body,
 		grid:       grid,
+		empty:      empty,
+		table:      table,
+		compact:    compact,
+		view:       view,
 		views:      views,
 		viewMap:    viewMap,
+		summary:    summary,
 		statusBar:  statusBar,
 		throughput: monitor.NewThroughputTracker(),
 		theme:      theme,
@@ -113,6 +226,18 @@ func newWindowPanel(win config.WindowConfig, theme Theme) *WindowPanel {
 	}
 }
 
+// toggleView switches the window to the next view mode.
+func (p *WindowPanel) toggleView() {
+	i := slices.Index(config.Views, p.view)
+	p.setView(config.Views[(i+1)%len(config.Views)])
+}
+
+// setView switches the window to a view mode.
+func (p *WindowPanel) setView(view string) {
+	p.view = view
+	p.body.SwitchToPage(view)
+}
+
 // copyToClipboard copies text to the system clipboard.
 func copyToClipboard(text string) error {
 	var cmd *exec.Cmd
@@ -137,15 +262,15 @@ func copyToClipboard(text string) error {
 }
 
 // SetupViews initializes the grid with views for each consumer in a panel.
+// It must run on the UI goroutine.
 func (p *WindowPanel) SetupViews(allStates []monitor.ConsumerState) {
 	// Build a map of all states for quick lookup
 	stateMap := make(map[string]monitor.ConsumerState)
 	for _, state := range allStates {
-		key := state.Ref.Stream + "/" + state.Ref.Consumer
+		key := state.Ref.Key()
 		stateMap[key] = state
 	}
 
-	columns := p.config.Columns
 	for i, ref := range p.config.Consumers {
 		tv := NewSelectableTextView()
 		tv.SetDynamicColors(true)
@@ -158,61 +283,47 @@ func (p *WindowPanel) SetupViews(allStates []monitor.ConsumerState) {
 		})
 
 		p.views[i] = tv
-		key := ref.Stream + "/" + ref.Consumer
+		key := ref.Key()
 		p.viewMap[key] = tv
+	}
 
-		row := i / columns
-		col := i % columns
-
-		p.grid.AddItem(tv, row, col, 1, 1, 0, 0, false)
+	if p.visible == nil {
+		p.visible = make([]int, len(p.views))
+		for i := range p.visible {
+			p.visible[i] = i
+		}
 	}
+	p.layoutGrid()
 }
 
 // Run starts the UI event loop.
-func (a *App) Run(ctx context.Context, updates <-chan []monitor.ConsumerState) error {
+func (a *App) Run(ctx context.Context, sub *monitor.Subscription) error {
 	// Handle updates from poller
-	go a.handleUpdates(ctx, updates)
+	go a.handleUpdates(ctx, sub)
 
 	// Set up keyboard handler
 	a.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		// Handle Ctrl-C
+		// Ctrl-C quits whatever the key map and focus
 		if event.Key() == tcell.KeyCtrlC {
 			a.app.Stop()
 			return nil
 		}
-
-		switch event.Rune() {
-		case 't', 'T':
-			a.toggleThroughput()
-			return nil
-		case 'c', 'C':
-			a.clearThroughput()
-			return nil
-		case 'q', 'Q':
-			a.app.Stop()
-			return nil
-		case '<', ',':
-			a.prevWindow()
-			return nil
-		case '>', '.':
-			a.nextWindow()
-			return nil
-		}
-
-		// Handle arrow keys for window navigation
-		switch event.Key() {
-		case tcell.KeyLeft:
-			a.prevWindow()
-			return nil
-		case tcell.KeyRight:
-			a.nextWindow()
-			return nil
+		if a.dialogOpen || !a.keys.handle(a, event) {
+			return event
 		}
-
-		return event
+		return nil
 	})
 
-	return a.app.SetRoot(a.pages, true).EnableMouse(true).Run()
+	return a.app.SetRoot(a.root, true).EnableMouse(true).Run()
+}
+
+// SelectWindow makes the window at idx the visible one.
+func (a *App) SelectWindow(idx int) {
+	if idx < 0 || idx >= len(a.panels) {
+		return
+	}
+	a.currentIdx = idx
+	a.showWindow()
 }
 
 func (a *App) prevWindow() {
@@ -223,8 +334,7 @@ func (a *App) prevWindow() {
 	if a.currentIdx < 0 {
 		a.currentIdx = len(a.panels) - 1
 	}
-	a.pages.SwitchToPage(fmt.Sprintf(windowPageFmt, a.currentIdx))
-	a.updateWindowTitle()
+	a.showWindow()
 }
 
 func (a *App) nextWindow() {
@@ -235,20 +345,62 @@ func (a *App) nextWindow() {
 	if a.currentIdx >= len(a.panels) {
 		a.currentIdx = 0
 	}
+	a.showWindow()
+}
+
+// showWindow switches to the current window, leaving the debug and skew
+// views.
+func (a *App) showWindow() {
+	a.debugShown = false
+	a.skewShown = false
 	a.pages.SwitchToPage(fmt.Sprintf(windowPageFmt, a.currentIdx))
-	a.updateWindowTitle()
+	a.app.SetFocus(a.pages)
+	a.refreshStatus()
 }
 
-func (a *App) updateWindowTitle() {
-	if len(a.panels) <= 1 {
+// turnPage pages through the grid of the current window. It reports
+// whether the grid has several pages.
+func (a *App) turnPage(delta int) bool {
+	if a.debugShown || a.skewShown || !a.panels[a.currentIdx].turnPage(delta) {
+		return false
+	}
+	a.refreshStatus()
+	return true
+}
+
+// toggleView switches the current window to the next view mode.
+func (a *App) toggleView() {
+	if a.debugShown || a.skewShown {
+		a.showWindow()
+	}
+	a.panels[a.currentIdx].toggleView()
+	a.app.SetFocus(a.pages)
+	a.refreshStatus()
+}
+
+func (a *App) toggleDebug() {
+	if a.debugShown {
+		a.showWindow()
+		return
+	}
+	a.skewShown = false
+	a.debugShown = true
+	a.pages.SwitchToPage(debugPage)
+}
+
+// toggleSkew shows how the consumers of the current window compare.
+func (a *App) toggleSkew() {
+	if a.skewShown {
+		a.showWindow()
 		return
 	}
-	panel := a.panels[a.currentIdx]
-	title := fmt.Sprintf("[green]%s[-] (%d/%d)", panel.config.Name, a.currentIdx+1, len(a.panels))
-	panel.statusBar.SetText(title + " [dim]| 't' throughput | 'c' clear | '<'/'>' windows | 'q'/Ctrl-C quit[-]")
+	a.debugShown = false
+	a.skewShown = true
+	a.skew.Render(a.panels[a.currentIdx].config, a.lastStates)
+	a.pages.SwitchToPage(skewPage)
 }
 
-func (a *App) handleUpdates(ctx context.Context, updates <-chan []monitor.ConsumerState) {
+func (a *App) handleUpdates(ctx context.Context, sub *monitor.Subscription) {
 	firstUpdate := true
 
 	for {
@@ -256,22 +408,48 @@ func (a *App) handleUpdates(ctx context.Context, updates <-chan []monitor.Consum
 		case <-ctx.Done():
 			a.app.Stop()
 			return
-		case states := <-updates:
+		case update, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			states := update.States
 			if firstUpdate {
-				// Setup views for all panels
-				for _, panel := range a.panels {
-					panel.SetupViews(states)
+				// Setup views for all panels on the UI goroutine, which
+				// lays out the grids too, and wait so the views exist
+				// before they are updated below
+				done := make(chan struct{})
+				a.app.QueueUpdateDraw(func() {
+					for _, panel := range a.panels {
+						panel.SetupViews(states)
+					}
+					close(done)
+				})
+				select {
+				case <-done:
+				case <-ctx.Done():
+					a.app.Stop()
+					return
 				}
 				firstUpdate = false
-				a.updateWindowTitle()
 			}
-			a.lastStates = states
 			// Update all panels with new states
 			for _, panel := range a.panels {
 				panel.throughput.Update(states)
 				panel.updateViews(a.app, states)
-				panel.updateStatusBar(a.app, states, a.currentIdx, len(a.panels))
+				panel.updateTable(a.app, states)
+				panel.updateCompact(a.app, states)
+				panel.updateSummary(a.app, states)
 			}
+			a.app.QueueUpdateDraw(func() {
+				a.lastStates = states
+				a.stats = &update.Stats
+				a.applyFilter(states)
+				a.refreshStatus()
+				a.debug.Record(update.Stats, time.Since(update.Stats.PublishedAt()), sub.Dropped(), states)
+				if a.skewShown {
+					a.skew.Render(a.panels[a.currentIdx].config, states)
+				}
+			})
 		}
 	}
 }
@@ -282,62 +460,49 @@ func (a *App) toggleThroughput() {
 	}
 	// Toggle throughput on all panels
 	for _, panel := range a.panels {
-		measuring := panel.throughput.Toggle(a.lastStates)
-		// Update status bar directly (we're on the main thread from input handler)
-		if measuring {
-			panel.statusBar.SetText("[green]▶ Measuring...[-] 't' to stop")
-		} else {
-			panel.statusBar.SetText("[yellow]■ Done[-] 't' restart | 'c' clear | '<'/'>' windows | double-click to copy")
-		}
+		panel.throughput.Toggle(a.lastStates)
 	}
+	a.refreshStatus()
 }
 
-func (a *App) clearThroughput() {
-	// Clear throughput on all panels
+// timedThroughput starts a measurement of the configured length on all
+// panels, or stops the running one.
+func (a *App) timedThroughput() {
+	if a.lastStates == nil || a.throughput.Duration <= 0 {
+		return
+	}
 	for _, panel := range a.panels {
-		panel.throughput.Clear()
-		panel.statusBar.SetText(defaultStatusText)
+		if !panel.throughput.StartFor(a.lastStates, a.throughput.Duration.Duration()) {
+			panel.throughput.Stop()
+		}
 	}
+	a.refreshStatus()
 }
 
-func (p *WindowPanel) updateStatusBar(app *tview.Application, states []monitor.ConsumerState, currentIdx, totalPanels int) {
-	if p.throughput.IsMeasuring() {
-		return // Don't update while measuring - status is set by toggleThroughput
-	}
+// SetThroughput sets the length of measurements started with 'b' and where
+// 'e' exports the results.
+func (a *App) SetThroughput(cfg config.ThroughputConfig) {
+	a.throughput = cfg
+}
 
-	// Check if we have measurement results to display
-	hasResults := false
-	for _, ref := range p.config.Consumers {
-		if m := p.throughput.Get(ref.Stream, ref.Consumer); m != nil {
-			hasResults = true
-			break
-		}
+func (a *App) clearThroughput() {
+	// Clear throughput on all panels
+	for _, panel := range a.panels {
+		panel.throughput.Clear()
 	}
-
-	// This is called from handleUpdates goroutine, so use QueueUpdateDraw
-	app.QueueUpdateDraw(func() {
-		var prefix string
-		if totalPanels > 1 {
-			prefix = fmt.Sprintf("[green]%s[-] (%d/%d) | ", p.config.Name, currentIdx+1, totalPanels)
-		}
-		if hasResults {
-			p.statusBar.SetText(prefix + "[yellow]■ Done[-] 't' restart | 'c' clear | '<'/'>' windows | double-click to copy")
-		} else {
-			p.statusBar.SetText(prefix + defaultStatusText)
-		}
-	})
+	a.refreshStatus()
 }
 
 func (p *WindowPanel) updateViews(app *tview.Application, states []monitor.ConsumerState) {
 	// Build a map of states for quick lookup
 	stateMap := make(map[string]monitor.ConsumerState)
 	for _, state := range states {
-		key := state.Ref.Stream + "/" + state.Ref.Consumer
+		key := state.Ref.Key()
 		stateMap[key] = state
 	}
 
 	for _, ref := range p.config.Consumers {
-		key := ref.Stream + "/" + ref.Consumer
+		key := ref.Key()
 		tv := p.viewMap[key]
 		if tv == nil {
 			continue
@@ -365,50 +530,28 @@ func (p *WindowPanel) updateViews(app *tview.Application, states []monitor.Consu
 	}
 }
 
+// formatConsumerState renders the cell of a consumer with the window's
+// template.
 func (p *WindowPanel) formatConsumerState(state monitor.ConsumerState) string {
-	if state.Error != nil {
-		return fmt.Sprintf("[red]ERROR[-]\n%v", state.Error)
-	}
-
-	ci := state.Info
-	base := fmt.Sprintf(
-		"[yellow]Last Delivered:[-] Consumer seq: %s  Stream seq: %s  Last delivery: %s\n"+
-			"[yellow]Ack Floor:[-]    Consumer seq: %s  Stream seq: %s  Last ack: %s\n"+
-			"[yellow]Outstanding Acks:[-] %d of max %d\n"+
-			"[yellow]Redelivered:[-] %d\n"+
-			"[yellow]Unprocessed:[-] %d\n"+
-			"[yellow]Waiting Pulls:[-] %d of max %d",
-		FormatInt(ci.Delivered.Consumer),
-		FormatInt(ci.Delivered.Stream),
-		Ago(ci.Delivered.Last),
-		FormatInt(ci.AckFloor.Consumer),
-		FormatInt(ci.AckFloor.Stream),
-		Ago(ci.AckFloor.Last),
-		ci.NumAckPending,
-		ci.Config.MaxAckPending,
-		ci.NumRedelivered,
-		ci.NumPending,
-		ci.NumWaiting,
-		ci.Config.MaxWaiting,
-	)
-
-	// Add throughput info if available
-	if m := p.throughput.Get(state.Ref.Stream, state.Ref.Consumer); m != nil {
-		throughputInfo := fmt.Sprintf(
-			"\n[cyan]─── Throughput ───[-]\n"+
-				"[cyan]Duration:[-] %s\n"+
-				"[cyan]Delivered:[-] %s msgs (%.1f/s)\n"+
-				"[cyan]Acked:[-] %s msgs (%.1f/s)",
-			m.Duration().Round(time.Second),
-			FormatInt(m.DeliveredCount()),
-			m.DeliveredRate(),
-			FormatInt(m.AckedCount()),
-			m.AckedRate(),
-		)
-		base += throughputInfo
-	}
-
-	return base
+	data := cellData{
+		Ref:        state.Ref,
+		Info:       state.Info,
+		Snapshot:   state.Snapshot,
+		Rates:      state.Rates,
+		Error:      state.Error,
+		Throughput: p.throughput.Get(state.Ref.Stream, state.Ref.Consumer),
+	}
+	if b := p.baseline.Load(); b != nil && data.Throughput != nil {
+		if c, ok := b.Compare(state.Ref, *data.Throughput, p.threshold); ok {
+			data.Baseline = &cellBaseline{Name: b.Name, Comparison: c}
+		}
+	}
+
+	var text strings.Builder
+	if err := p.template.Execute(&text, data); err != nil {
+		return paint(p.theme.ErrorText, "TEMPLATE ERROR") + "\n" + tview.Escape(err.Error())
+	}
+	return text.String()
 }
 
 // Stop gracefully stops the application.
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+const dialogPage = "dialog"
+
+// SetBaselines sets the store used to save throughput baselines and
+// compare against them, and the rate drop in percent flagged as a
+// regression. It must be called before Run.
+func (a *App) SetBaselines(store *baseline.Store, threshold float64) {
+	a.baselines = store
+	for _, panel := range a.panels {
+		panel.threshold = threshold
+	}
+}
+
+// saveBaseline asks for a name and saves the finished measurement under it.
+func (a *App) saveBaseline() {
+	if a.baselines == nil {
+		return
+	}
+	// Every window is saved, so none may still be measuring
+	for _, panel := range a.panels {
+		if panel.throughput.IsMeasuring() {
+			a.showNotice("Stop the throughput measurement before saving a baseline", a.theme.WarningText)
+			return
+		}
+	}
+	measurements := a.allMeasurements()
+	if len(measurements) == 0 {
+		a.showNotice("No finished throughput results to save", a.theme.WarningText)
+		return
+	}
+
+	input := tview.NewInputField().
+		SetLabel("Save baseline as: ").
+		SetFieldWidth(0)
+	input.SetBorder(true).SetTitle(" Baseline ")
+	input.SetDoneFunc(func(key tcell.Key) {
+		a.closeDialog()
+		if key != tcell.KeyEnter {
+			return
+		}
+		name := strings.TrimSpace(input.GetText())
+		b, err := a.baselines.Save(name, measurements)
+		if err != nil {
+			a.showNotice("✗ baseline: "+err.Error(), a.theme.ErrorText)
+			return
+		}
+		a.showNotice(fmt.Sprintf("Saved baseline %q with %d consumers to %s", b.Name, len(b.Consumers), a.baselines.Path()), a.theme.OK)
+	})
+	a.openDialog(input, 60, 3)
+}
+
+// chooseBaseline lets the user pick the baseline measurements are compared
+// against.
+func (a *App) chooseBaseline() {
+	if a.baselines == nil {
+		return
+	}
+	names := a.baselines.Names()
+	if len(names) == 0 {
+		text := "No saved baselines"
+		if key := a.keys.hint("after a measurement to save one", actionSaveBaseline); key != "" {
+			text += ", press " + key
+		}
+		a.showNotice(text, a.theme.WarningText)
+		return
+	}
+
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle(" Compare with ")
+	list.AddItem("None", "", 0, func() {
+		a.closeDialog()
+		a.baseline.Store(nil)
+		a.showNotice("Not comparing with a baseline", a.theme.OK)
+	})
+	current := a.baseline.Load()
+	for i, name := range names {
+		b, _ := a.baselines.Get(name)
+		list.AddItem(fmt.Sprintf("%s (%s)", tview.Escape(name), b.SavedAt.Format("2006-01-02 15:04")), "", 0, func() {
+			a.closeDialog()
+			a.baseline.Store(b)
+			a.showNotice(fmt.Sprintf("Comparing throughput with baseline %q", name), a.theme.OK)
+		})
+		if current != nil && current.Name == name {
+			list.SetCurrentItem(i + 1)
+		}
+	}
+	list.SetDoneFunc(a.closeDialog)
+	a.openDialog(list, 60, min(len(names)+3, 20))
+}
+
+// allMeasurements merges the throughput measurements of every window.
+func (a *App) allMeasurements() map[string]monitor.ThroughputMeasurement {
+	all := make(map[string]monitor.ThroughputMeasurement)
+	for _, panel := range a.panels {
+		for key, m := range panel.throughput.All() {
+			all[key] = m
+		}
+	}
+	return all
+}
+
+// openDialog shows p centered over the current window. While it is open,
+// keys go to the dialog instead of the global shortcuts.
+func (a *App) openDialog(p tview.Primitive, width, height int) {
+	modal := tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+	a.dialogOpen = true
+	a.pages.AddPage(dialogPage, modal, true, true)
+	a.app.SetFocus(p)
+}
+
+func (a *App) closeDialog() {
+	a.dialogOpen = false
+	a.pages.RemovePage(dialogPage)
+}
+
+// formatComparison renders the change of a consumer or window against the
+// baseline. Regressions are red.
+func formatComparison(theme Theme, name string, c baseline.Comparison) string {
+	return fmt.Sprintf("%svs %s:[-] delivered %s, acked %s",
+		tag(theme.Accent), tview.Escape(name), formatChange(theme, c.Delivered), formatChange(theme, c.Acked))
+}
+
+func formatChange(theme Theme, c baseline.Change) string {
+	text := fmt.Sprintf("%+.1f/s", c.Delta)
+	if c.Baseline != 0 {
+		text += fmt.Sprintf(" (%+.1f%%)", c.Percent)
+	}
+	if c.Regressed {
+		return paint(theme.ErrorText, text)
+	}
+	return text
+}
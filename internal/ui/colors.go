@@ -1,8 +1,20 @@
 package ui
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
 
-// Theme defines the color scheme for the UI.
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// Theme defines the color scheme for the UI. Every color the UI draws comes
+// from one of its roles.
 type Theme struct {
 	Background  tcell.Color
 	Border      tcell.Color
@@ -11,6 +23,99 @@ type Theme struct {
 	Text        tcell.Color
 	ErrorText   tcell.Color
 	WarningText tcell.Color
+	Label       tcell.Color // Field names and headers
+	Accent      tcell.Color // Throughput results and baseline comparisons
+	OK          tcell.Color // Healthy consumers and confirmations
+	Muted       tcell.Color // Key hints and secondary text
+}
+
+// themePreset is a built-in theme, with the colors it uses on terminals
+// with 16 colors or fewer.
+type themePreset struct {
+	full  Theme
+	basic Theme
+}
+
+var themePresets = map[string]themePreset{
+	config.ThemeDark: {
+		full: DefaultTheme(),
+		basic: Theme{
+			Background:  tcell.ColorDefault,
+			Border:      tcell.ColorGray,
+			Flash:       tcell.ColorNavy,
+			Title:       tcell.ColorBlue,
+			Text:        tcell.ColorDefault,
+			ErrorText:   tcell.ColorRed,
+			WarningText: tcell.ColorYellow,
+			Label:       tcell.ColorYellow,
+			Accent:      tcell.ColorAqua,
+			OK:          tcell.ColorLime,
+			Muted:       tcell.ColorGray,
+		},
+	},
+	config.ThemeLight: {
+		full: Theme{
+			Background:  tcell.NewRGBColor(239, 241, 245),
+			Border:      tcell.NewRGBColor(156, 160, 176),
+			Flash:       tcell.NewRGBColor(179, 204, 255),
+			Title:       tcell.NewRGBColor(30, 102, 245),
+			Text:        tcell.NewRGBColor(76, 79, 105),
+			ErrorText:   tcell.NewRGBColor(210, 15, 57),
+			WarningText: tcell.NewRGBColor(200, 110, 0),
+			Label:       tcell.NewRGBColor(30, 102, 245),
+			Accent:      tcell.NewRGBColor(23, 146, 153),
+			OK:          tcell.NewRGBColor(64, 160, 43),
+			Muted:       tcell.NewRGBColor(140, 143, 161),
+		},
+		basic: Theme{
+			Background:  tcell.ColorDefault,
+			Border:      tcell.ColorGray,
+			Flash:       tcell.ColorSilver,
+			Title:       tcell.ColorNavy,
+			Text:        tcell.ColorDefault,
+			ErrorText:   tcell.ColorMaroon,
+			WarningText: tcell.ColorOlive,
+			Label:       tcell.ColorNavy,
+			Accent:      tcell.ColorTeal,
+			OK:          tcell.ColorGreen,
+			Muted:       tcell.ColorGray,
+		},
+	},
+	config.ThemeHighContrast: {
+		full:  highContrastTheme(),
+		basic: highContrastTheme(),
+	},
+	// Okabe-Ito colors, which stay apart with all common kinds of color
+	// blindness. Healthy is blue and failing is vermillion, never green
+	// against red.
+	config.ThemeColorblind: {
+		full: Theme{
+			Background:  tcell.NewRGBColor(24, 24, 37),
+			Border:      tcell.NewRGBColor(88, 91, 112),
+			Flash:       tcell.NewRGBColor(0, 114, 178),
+			Title:       tcell.NewRGBColor(86, 180, 233),
+			Text:        tcell.ColorWhite,
+			ErrorText:   tcell.NewRGBColor(213, 94, 0),
+			WarningText: tcell.NewRGBColor(240, 228, 66),
+			Label:       tcell.NewRGBColor(230, 159, 0),
+			Accent:      tcell.NewRGBColor(86, 180, 233),
+			OK:          tcell.NewRGBColor(0, 158, 115),
+			Muted:       tcell.NewRGBColor(150, 150, 150),
+		},
+		basic: Theme{
+			Background:  tcell.ColorDefault,
+			Border:      tcell.ColorGray,
+			Flash:       tcell.ColorNavy,
+			Title:       tcell.ColorAqua,
+			Text:        tcell.ColorDefault,
+			ErrorText:   tcell.ColorFuchsia,
+			WarningText: tcell.ColorYellow,
+			Label:       tcell.ColorYellow,
+			Accent:      tcell.ColorAqua,
+			OK:          tcell.ColorBlue,
+			Muted:       tcell.ColorGray,
+		},
+	},
 }
 
 // DefaultTheme returns the default dark theme.
@@ -23,5 +128,180 @@ func DefaultTheme() Theme {
 		Text:        tcell.ColorWhite,
 		ErrorText:   tcell.ColorRed,
 		WarningText: tcell.ColorYellow,
+		Label:       tcell.ColorYellow,
+		Accent:      tcell.ColorAqua,
+		OK:          tcell.ColorGreen,
+		Muted:       tcell.NewRGBColor(108, 112, 134),
+	}
+}
+
+// highContrastTheme uses the basic terminal colors at full brightness on
+// black, so it looks the same on every terminal.
+func highContrastTheme() Theme {
+	return Theme{
+		Background:  tcell.ColorBlack,
+		Border:      tcell.ColorWhite,
+		Flash:       tcell.ColorBlue,
+		Title:       tcell.ColorWhite,
+		Text:        tcell.ColorWhite,
+		ErrorText:   tcell.ColorRed,
+		WarningText: tcell.ColorYellow,
+		Label:       tcell.ColorAqua,
+		Accent:      tcell.ColorFuchsia,
+		OK:          tcell.ColorLime,
+		Muted:       tcell.ColorSilver,
+	}
+}
+
+// monochromeTheme leaves every color to the terminal.
+func monochromeTheme() Theme {
+	d := tcell.ColorDefault
+	return Theme{d, d, d, d, d, d, d, d, d, d, d}
+}
+
+// LoadTheme builds the theme configured in cfg for the terminal: the preset
+// with the configured colors on top, reduced to the basic colors on
+// terminals with 16 colors or fewer. With $NO_COLOR set, or the "none"
+// palette, the theme is monochrome.
+func LoadTheme(cfg config.ThemeConfig) (Theme, error) {
+	preset, ok := themePresets[cfg.Preset]
+	if !ok {
+		preset = themePresets[config.ThemeDark]
+	}
+
+	palette := cfg.Palette
+	if palette == "" || palette == config.PaletteAuto {
+		palette = detectPalette()
+	}
+	if os.Getenv("NO_COLOR") != "" || palette == config.PaletteNone {
+		return monochromeTheme(), nil
+	}
+
+	theme := preset.full
+	basic := palette == config.Palette16
+	if basic {
+		theme = preset.basic
+	}
+	roles := theme.roles()
+	for role, value := range cfg.Colors {
+		c, ok := roles[role]
+		if !ok {
+			return Theme{}, fmt.Errorf("theme: unknown color role %q (want %s)", role, strings.Join(themeRoles, ", "))
+		}
+		color := tcell.GetColor(value)
+		if color == tcell.ColorDefault && value != "default" {
+			return Theme{}, fmt.Errorf("theme: invalid color %q for %s", value, role)
+		}
+		if basic && color != tcell.ColorDefault {
+			color = tcell.FindColor(color, basicColors)
+		}
+		*c = color
+	}
+	return theme, nil
+}
+
+// themeRoles are the names of the theme colors in the config file.
+var themeRoles = []string{"background", "border", "flash", "title", "text", "error", "warning", "label", "accent", "ok", "muted"}
+
+// roles maps the role names to the colors of the theme.
+func (t *Theme) roles() map[string]*tcell.Color {
+	colors := []*tcell.Color{&t.Background, &t.Border, &t.Flash, &t.Title, &t.Text, &t.ErrorText, &t.WarningText, &t.Label, &t.Accent, &t.OK, &t.Muted}
+	roles := make(map[string]*tcell.Color, len(themeRoles))
+	for i, name := range themeRoles {
+		roles[name] = colors[i]
+	}
+	return roles
+}
+
+// role returns the color of a role, or false if there is no such role.
+func (t Theme) role(name string) (tcell.Color, bool) {
+	c, ok := t.roles()[name]
+	if !ok {
+		return tcell.ColorDefault, false
+	}
+	return *c, true
+}
+
+// basicColors are the 16 colors every color terminal has.
+var basicColors = func() []tcell.Color {
+	colors := make([]tcell.Color, 16)
+	for i := range colors {
+		colors[i] = tcell.PaletteColor(i)
+	}
+	return colors
+}()
+
+// detectPalette finds the colors of the terminal from $TERM and
+// $COLORTERM the way tcell does.
+func detectPalette() string {
+	ti, err := terminfo.LookupTerminfo(os.Getenv("TERM"))
+	switch {
+	case err != nil:
+		return config.Palette256 // Unknown terminal; leave the mapping to tcell
+	case ti.SetFgRGB != "" && os.Getenv("TCELL_TRUECOLOR") != "disable":
+		return config.PaletteTrueColor
+	case ti.Colors >= 256:
+		return config.Palette256
+	case ti.Colors > 0:
+		return config.Palette16
+	default:
+		return config.PaletteNone
+	}
+}
+
+// applyStyles makes the theme the default of tview primitives, such as the
+// dialogs.
+func (t Theme) applyStyles() {
+	tview.Styles = tview.Theme{
+		PrimitiveBackgroundColor:    t.Background,
+		ContrastBackgroundColor:     t.Border,
+		MoreContrastBackgroundColor: t.Flash,
+		BorderColor:                 t.Border,
+		TitleColor:                  t.Title,
+		GraphicsColor:               t.Border,
+		PrimaryTextColor:            t.Text,
+		SecondaryTextColor:          t.Label,
+		TertiaryTextColor:           t.Accent,
+		InverseTextColor:            t.Background,
+		ContrastSecondaryTextColor:  t.Muted,
+	}
+}
+
+// colorName returns the name of c in a tview color tag, "-" for the
+// default color.
+func colorName(c tcell.Color) string {
+	if c == tcell.ColorDefault {
+		return "-"
+	}
+	return c.String()
+}
+
+// tag returns the tview color tag that switches the foreground to c.
+func tag(c tcell.Color) string {
+	return "[" + colorName(c) + "]"
+}
+
+// paint colors text for a dynamic-color text view.
+func paint(c tcell.Color, text string) string {
+	return tag(c) + text + "[-]"
+}
+
+// badge renders text in the background color on fg, such as the filter
+// indicator. Without a background color, it is shown reversed.
+func badge(fg, bg tcell.Color, text string) string {
+	if bg == tcell.ColorDefault {
+		return "[" + colorName(fg) + "::r]" + text + "[-::-]"
+	}
+	return fmt.Sprintf("[%s:%s]%s[-:-]", colorName(bg), colorName(fg), text)
+}
+
+// themeColor resolves a role name or a color name for templates.
+func (t Theme) themeColor(name string) tcell.Color {
+	if c, ok := t.role(name); ok {
+		return c
+	}
+	if slices.Contains(themeRoles, name) {
+		return tcell.ColorDefault
 	}
+	return tcell.GetColor(name)
 }
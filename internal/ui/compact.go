@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/rivo/uniseg"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/health"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+const (
+	compactMaxName = 32 // Longer consumer names are cut from the left
+	compactGap     = 3  // Spaces between columns of entries
+)
+
+// compactView shows one line per consumer, in as many columns as the width
+// allows, so that a service with a hundred partitions fits on one screen.
+// Lines of consumers that changed flash.
+type compactView struct {
+	*tview.TextView
+	theme      Theme
+	refs       []config.ConsumerRef
+	listed     map[string]bool
+	visible    map[string]bool // Consumers shown; nil shows all
+	states     map[string]monitor.ConsumerState
+	flashUntil map[string]time.Time
+	nameWidth  int
+
+	// status summarizes the health of a consumer as a glyph and a color
+	status func(monitor.ConsumerState, time.Time) (string, tcell.Color)
+}
+
+func newCompactView(refs []config.ConsumerRef, theme Theme) *compactView {
+	tv := tview.NewTextView()
+	tv.SetDynamicColors(true)
+	tv.SetWrap(false)
+	tv.SetBackgroundColor(theme.Background)
+	tv.SetBorder(true)
+	tv.SetBorderColor(theme.Border)
+
+	c := &compactView{
+		TextView:   tv,
+		theme:      theme,
+		refs:       refs,
+		listed:     make(map[string]bool),
+		states:     make(map[string]monitor.ConsumerState),
+		flashUntil: make(map[string]time.Time),
+		nameWidth:  len("Consumer"),
+	}
+	for _, ref := range refs {
+		c.listed[ref.Key()] = true
+		c.nameWidth = max(c.nameWidth, min(uniseg.StringWidth(ref.Consumer), compactMaxName))
+	}
+	return c
+}
+
+// Update records new consumer states and starts the flash of those that
+// changed. It returns when the last flash ends, or the zero time if nothing
+// changed. It must be called from the UI goroutine.
+func (c *compactView) Update(states []monitor.ConsumerState, now time.Time) time.Time {
+	var until time.Time
+	for _, state := range states {
+		key := state.Ref.Key()
+		if !c.listed[key] {
+			continue
+		}
+		c.states[key] = state
+		if state.Changed && state.Error == nil {
+			until = now.Add(flashDuration)
+			c.flashUntil[key] = until
+		}
+	}
+	return until
+}
+
+// SetVisible shows only the consumers whose keys are in visible, or all of
+// them if visible is nil.
+func (c *compactView) SetVisible(visible map[string]bool) {
+	c.visible = visible
+}
+
+// Draw lays the entries out in columns for the current width, then draws
+// the view.
+func (c *compactView) Draw(screen tcell.Screen) {
+	_, _, width, _ := c.GetInnerRect()
+	c.SetText(c.render(width, time.Now()))
+	c.TextView.Draw(screen)
+}
+
+// render lays out the visible consumers column by column.
+func (c *compactView) render(width int, now time.Time) string {
+	var refs []config.ConsumerRef
+	seen := make(map[string]bool)
+	for _, ref := range c.refs {
+		key := ref.Key()
+		if seen[key] || (c.visible != nil && !c.visible[key]) {
+			continue
+		}
+		seen[key] = true
+		refs = append(refs, ref)
+	}
+	if len(refs) == 0 {
+		return paint(c.theme.WarningText, "No consumers match the filter")
+	}
+
+	header := fmt.Sprintf("  %-*s %11s %8s %9s %8s", c.nameWidth, "Consumer", "Unprocessed", "Ack Pend", "Acked/s", "Last Ack")
+	entryWidth := len([]rune(header))
+	columns := max(1, min((width+compactGap)/(entryWidth+compactGap), len(refs)))
+	rows := (len(refs) + columns - 1) / columns
+
+	var b strings.Builder
+	for col := range columns {
+		if col > 0 {
+			b.WriteString(strings.Repeat(" ", compactGap))
+		}
+		b.WriteString(paint(c.theme.Label, tview.Escape(header)))
+	}
+	for row := range rows {
+		b.WriteString("\n")
+		for col := range columns {
+			i := col*rows + row
+			if i >= len(refs) {
+				break
+			}
+			if col > 0 {
+				b.WriteString(strings.Repeat(" ", compactGap))
+			}
+			b.WriteString(c.entry(refs[i], now))
+		}
+	}
+	return b.String()
+}
+
+// fitName pads a consumer name to width screen columns. Longer names are
+// cut from the left behind an ellipsis, by whole characters.
+func fitName(name string, width int) string {
+	w := uniseg.StringWidth(name)
+	if w > width {
+		g := uniseg.NewGraphemes(name)
+		for w > width-1 && g.Next() {
+			w -= g.Width()
+		}
+		_, end := g.Positions()
+		name, w = "…"+name[end:], w+1
+	}
+	return name + strings.Repeat(" ", max(width-w, 0))
+}
+
+// entry formats the line of one consumer.
+func (c *compactView) entry(ref config.ConsumerRef, now time.Time) string {
+	name := fitName(ref.Consumer, c.nameWidth)
+
+	state, ok := c.states[ref.Key()]
+	var line string
+	glyph, color := "·", c.theme.Text
+	switch {
+	case !ok:
+		line = fmt.Sprintf("%s %11s %8s %9s %8s", name, "", "", "", "")
+	case state.Error != nil:
+		line = fmt.Sprintf("%s %-39s", name, "ERROR")
+	default:
+		snap := state.Snapshot
+		line = fmt.Sprintf("%s %11s %8s %9.1f %8s", name,
+			FormatInt(snap.NumPending), FormatInt(uint64(snap.NumAckPending)), state.Rates.Acked,
+			formatAge(health.LastAckAge(state, now)))
+	}
+	if ok && c.status != nil {
+		glyph, color = c.status(state, now)
+	}
+
+	text := paint(color, glyph) + " " + tview.Escape(line)
+	if ok && state.Error != nil {
+		text = paint(c.theme.ErrorText, glyph+" "+tview.Escape(line))
+	}
+	if now.Before(c.flashUntil[ref.Key()]) {
+		text = fmt.Sprintf("[:%s]%s[:-]", colorName(c.theme.Flash), text)
+	}
+	return text
+}
+
+// formatAge formats a duration in its largest whole unit, such as "42s" or
+// "3h".
+func formatAge(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// updateCompact passes new states to the compact view and redraws it when
+// the flashes of changed consumers end.
+func (p *WindowPanel) updateCompact(app *tview.Application, states []monitor.ConsumerState) {
+	app.QueueUpdateDraw(func() {
+		until := p.compact.Update(states, time.Now())
+		if until.IsZero() {
+			return
+		}
+		time.AfterFunc(time.Until(until), func() {
+			app.QueueUpdateDraw(func() {})
+		})
+	})
+}
@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+const (
+	debugPage        = "debug"
+	debugHistorySize = 20
+	debugSlowest     = 10
+)
+
+// debugSample records one poll cycle as seen by the UI.
+type debugSample struct {
+	stats   monitor.CycleStats
+	uiDelay time.Duration // From publish until the UI applied the update
+}
+
+// DebugView shows poll cycle and request latency so a slow cluster can be
+// told apart from a slow terminal.
+type DebugView struct {
+	view    *tview.TextView
+	theme   Theme
+	history []debugSample
+	dropped uint64
+	states  []monitor.ConsumerState
+}
+
+// NewDebugView creates the debug view.
+func NewDebugView(theme Theme) *DebugView {
+	tv := tview.NewTextView()
+	tv.SetDynamicColors(true)
+	tv.SetBackgroundColor(theme.Background)
+	tv.SetBorder(true)
+	tv.SetBorderColor(theme.Border)
+	return &DebugView{view: tv, theme: theme}
+}
+
+// Record adds a poll cycle to the history and re-renders the view.
+// It must be called from the UI goroutine.
+func (d *DebugView) Record(stats monitor.CycleStats, uiDelay time.Duration, dropped uint64, states []monitor.ConsumerState) {
+	d.history = append(d.history, debugSample{stats: stats, uiDelay: uiDelay})
+	if len(d.history) > debugHistorySize {
+		d.history = d.history[len(d.history)-debugHistorySize:]
+	}
+	d.dropped = dropped
+	d.states = states
+	d.render()
+}
+
+func (d *DebugView) render() {
+	var b strings.Builder
+
+	label := tag(d.theme.Label)
+	fmt.Fprintf(&b, "%sDropped UI updates:[-] %d\n\n", label, d.dropped)
+	fmt.Fprintf(&b, "%s%-10s %6s %5s %6s %9s %9s %9s %9s %9s[-]\n", label,
+		"Time", "Polled", "API", "Errors", "Cycle", "Req p50", "Req p95", "Req max", "UI delay")
+	for i := len(d.history) - 1; i >= 0; i-- {
+		s := d.history[i]
+		fmt.Fprintf(&b, "%-10s %6d %5d %6d %9s %9s %9s %9s %9s\n",
+			s.stats.Time.Format("15:04:05"),
+			s.stats.Polled,
+			s.stats.APICalls,
+			s.stats.Errors,
+			formatLatency(s.stats.Duration),
+			formatLatency(s.stats.Latency.P50),
+			formatLatency(s.stats.Latency.P95),
+			formatLatency(s.stats.Latency.Max),
+			formatLatency(s.uiDelay),
+		)
+	}
+
+	slowest := slices.Clone(d.states)
+	slices.SortFunc(slowest, func(a, b monitor.ConsumerState) int {
+		return cmp.Compare(b.Latency, a.Latency)
+	})
+	fmt.Fprintf(&b, "\n%sSlowest consumers (last request)[-]\n", label)
+	for i, state := range slowest {
+		if i == debugSlowest {
+			break
+		}
+		fmt.Fprintf(&b, "%9s  every %-6s %s\n",
+			formatLatency(state.Latency),
+			state.Interval,
+			tview.Escape(state.Ref.Key()),
+		)
+	}
+
+	d.view.SetText(b.String())
+}
+
+// formatLatency rounds a duration to a readable precision.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
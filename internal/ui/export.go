@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/export"
+)
+
+// exportThroughput writes the throughput results of every window to a file
+// in the configured format and directory, and reports the outcome in the
+// status bar.
+func (a *App) exportThroughput() {
+	now := time.Now()
+	report := export.Report{Time: now}
+	for _, panel := range a.panels {
+		if w := export.NewWindow(panel.config, panel.throughput.All()); len(w.Consumers) > 0 {
+			report.Windows = append(report.Windows, w)
+		}
+	}
+
+	if report.Len() == 0 {
+		a.showNotice("No throughput results to export", a.theme.WarningText)
+		return
+	}
+
+	format := a.throughput.ExportFormat
+	name := fmt.Sprintf("throughput-%s.%s", now.Format("20060102-150405"), export.Extension(format))
+	path := filepath.Join(a.throughput.ExportDir, name)
+	if err := writeExport(path, format, report); err != nil {
+		a.showNotice("✗ export: "+err.Error(), a.theme.ErrorText)
+		return
+	}
+	a.showNotice(fmt.Sprintf("Exported %d results to %s", report.Len(), path), a.theme.OK)
+}
+
+func writeExport(path, format string, report export.Report) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := export.Write(f, format, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
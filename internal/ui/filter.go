@@ -0,0 +1,288 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/health"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// defaultStallAfter is how long a consumer with outstanding messages may go
+// without acking before is:stalled matches it, unless alerts set their own.
+const defaultStallAfter = time.Minute
+
+// consumerFilter selects the consumers the windows show. All of its
+// conditions must hold.
+type consumerFilter struct {
+	query       string   // As typed after '/'
+	names       []string // Lower-case terms that must all appear in "stream/consumer"
+	errors      bool     // Only consumers that fail to fetch
+	stalled     bool     // Only consumers without ack progress
+	backlog     *uint64  // Only consumers with more unprocessed messages
+	hideHealthy bool
+}
+
+// parseFilter parses a search query: words match consumer and stream names,
+// "is:error" and "is:stalled" select by state and "backlog>N" by the number
+// of unprocessed messages.
+func parseFilter(query string) (consumerFilter, error) {
+	f := consumerFilter{query: strings.TrimSpace(query)}
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		switch {
+		case term == "is:error" || term == "is:err":
+			f.errors = true
+		case term == "is:stalled":
+			f.stalled = true
+		case strings.HasPrefix(term, "is:"):
+			return consumerFilter{}, fmt.Errorf("unknown state %q (want is:error or is:stalled)", term)
+		case strings.HasPrefix(term, "backlog>"):
+			n, err := strconv.ParseUint(strings.TrimPrefix(term, "backlog>"), 10, 64)
+			if err != nil {
+				return consumerFilter{}, fmt.Errorf("invalid backlog %q, want backlog>N", term)
+			}
+			f.backlog = &n
+		default:
+			f.names = append(f.names, term)
+		}
+	}
+	return f, nil
+}
+
+// active reports whether the filter hides anything.
+func (f consumerFilter) active() bool {
+	return f.query != "" || f.hideHealthy
+}
+
+// String describes the filter for the status bar.
+func (f consumerFilter) String() string {
+	var parts []string
+	if f.query != "" {
+		parts = append(parts, fmt.Sprintf("%q", f.query))
+	}
+	if f.hideHealthy {
+		parts = append(parts, "hiding healthy")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetHealth sets the check thresholds and stall timeout used to tell
+// healthy and stalled consumers apart when filtering. It must be called
+// before Run.
+func (a *App) SetHealth(cfg *config.Config) {
+	a.thresholds = cfg.ConsumerThresholds
+	if stall := cfg.Alerts.StallAfter.Duration(); stall > 0 {
+		a.stallAfter = stall
+	}
+}
+
+// matches reports whether the filter selects a consumer. Consumers that
+// have not been polled yet only match on their name.
+func (a *App) matches(ref config.ConsumerRef, state monitor.ConsumerState, polled bool, now time.Time) bool {
+	f := a.filter
+	key := strings.ToLower(ref.Key())
+	for _, name := range f.names {
+		if !strings.Contains(key, name) {
+			return false
+		}
+	}
+	if !polled {
+		return !f.errors && !f.stalled && f.backlog == nil
+	}
+	if f.errors && state.Error == nil {
+		return false
+	}
+	if f.stalled && !a.stalled(state, now) {
+		return false
+	}
+	if f.backlog != nil && (state.Error != nil || state.Snapshot.NumPending <= *f.backlog) {
+		return false
+	}
+	if f.hideHealthy && a.healthy(state, now) {
+		return false
+	}
+	return true
+}
+
+// stalled reports whether a consumer has outstanding messages but has not
+// acked any for the stall timeout.
+func (a *App) stalled(state monitor.ConsumerState, now time.Time) bool {
+	snap := state.Snapshot
+	if state.Error != nil || (snap.NumPending == 0 && snap.NumAckPending == 0) {
+		return false
+	}
+	return health.LastAckAge(state, now) >= a.stallAfter
+}
+
+// healthy reports whether a consumer passes its checks, is not stalled and
+// has no firing alert.
+func (a *App) healthy(state monitor.ConsumerState, now time.Time) bool {
+	if state.Error != nil || a.stalled(state, now) {
+		return false
+	}
+	var th config.Thresholds
+	if a.thresholds != nil {
+		th = a.thresholds(state.Ref)
+	}
+	if health.Evaluate(state, th, now).Status != health.OK {
+		return false
+	}
+	for _, e := range a.alerts {
+		if e.Ref == state.Ref {
+			return false
+		}
+	}
+	return true
+}
+
+// setFilter replaces the filter and reflows every window. It must run on
+// the UI goroutine.
+func (a *App) setFilter(f consumerFilter) {
+	a.filter = f
+	a.applyFilter(a.filterStates)
+}
+
+// applyFilter shows the consumers of every window that match the filter in
+// states. It must run on the UI goroutine.
+func (a *App) applyFilter(states []monitor.ConsumerState) {
+	a.filterStates = states
+	byKey := make(map[string]monitor.ConsumerState, len(states))
+	for _, state := range states {
+		byKey[state.Ref.Key()] = state
+	}
+
+	now := time.Now()
+	for _, panel := range a.panels {
+		var visible []int
+		for i, ref := range panel.config.Consumers {
+			state, polled := byKey[ref.Key()]
+			if !a.filter.active() || a.matches(ref, state, polled, now) {
+				visible = append(visible, i)
+			}
+		}
+		panel.setVisible(visible)
+	}
+	a.refreshStatus()
+}
+
+// openFilter asks for a search query, filtering the windows as it is typed.
+// Enter keeps the filter and jumps to a window with matches if the current
+// one has none; Escape restores the previous filter.
+func (a *App) openFilter() {
+	prev := a.filter
+	input := tview.NewInputField().
+		SetLabel("/").
+		SetText(prev.query).
+		SetFieldWidth(0)
+	input.SetBorder(true).SetTitle(" Filter: name, is:error, is:stalled, backlog>N ")
+	input.SetChangedFunc(func(text string) {
+		f, err := parseFilter(text)
+		if err != nil {
+			input.SetLabelColor(a.theme.ErrorText)
+			return
+		}
+		input.SetLabelColor(a.theme.WarningText)
+		f.hideHealthy = prev.hideHealthy
+		a.setFilter(f)
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		a.closeDialog()
+		if key != tcell.KeyEnter {
+			a.setFilter(prev)
+			return
+		}
+		f, err := parseFilter(input.GetText())
+		if err != nil {
+			a.setFilter(prev)
+			a.showNotice("✗ filter: "+err.Error(), a.theme.ErrorText)
+			return
+		}
+		f.hideHealthy = prev.hideHealthy
+		a.setFilter(f)
+		a.jumpToMatch()
+	})
+	a.openDialog(input, 70, 3)
+}
+
+// toggleHealthy hides or shows the consumers that are healthy.
+func (a *App) toggleHealthy() {
+	f := a.filter
+	f.hideHealthy = !f.hideHealthy
+	a.setFilter(f)
+}
+
+// clearFilter shows every consumer again. It reports whether a filter was
+// active.
+func (a *App) clearFilter() bool {
+	if !a.filter.active() {
+		return false
+	}
+	a.setFilter(consumerFilter{})
+	return true
+}
+
+// jumpToMatch switches to the first window with matching consumers if the
+// current one has none.
+func (a *App) jumpToMatch() {
+	if len(a.panels[a.currentIdx].visible) > 0 {
+		return
+	}
+	for i, panel := range a.panels {
+		if len(panel.visible) > 0 {
+			a.SelectWindow(i)
+			return
+		}
+	}
+}
+
+// setVisible shows the consumers at the given indexes of the window in
+// every view.
+func (p *WindowPanel) setVisible(visible []int) {
+	if slices.Equal(visible, p.visible) && p.visible != nil {
+		return
+	}
+	p.visible = visible
+	if visible == nil {
+		p.visible = []int{}
+	}
+
+	keys := make(map[string]bool, len(visible))
+	for _, i := range visible {
+		keys[p.config.Consumers[i].Key()] = true
+	}
+	p.table.SetVisible(keys)
+	p.compact.SetVisible(keys)
+	p.layoutGrid()
+}
+
+// filterStatus describes an active filter for the status bar of a window.
+func (a *App) filterStatus(p *WindowPanel) string {
+	if !a.filter.active() {
+		return ""
+	}
+	return badge(a.theme.WarningText, a.theme.Background, " FILTER ") + " " +
+		withHint(paint(a.theme.WarningText, fmt.Sprintf("%s: %d of %d shown", tview.Escape(a.filter.String()), len(p.visible), len(p.config.Consumers)))+" ",
+			a.keys.hint("clears", actionClearFilter))
+}
+
+// consumerStatus summarizes the health of a consumer as a glyph and color:
+// failing, stalled, failing a check or alerting, or healthy.
+func (a *App) consumerStatus(state monitor.ConsumerState, now time.Time) (string, tcell.Color) {
+	switch {
+	case state.Error != nil:
+		return "✗", a.theme.ErrorText
+	case a.stalled(state, now):
+		return "■", a.theme.ErrorText
+	case !a.healthy(state, now):
+		return "▲", a.theme.WarningText
+	default:
+		return "●", a.theme.OK
+	}
+}
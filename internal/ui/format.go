@@ -2,8 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
 )
 
 // FormatInt formats an integer with thousand separators.
@@ -28,6 +32,11 @@ func FormatInt(n uint64) string {
 	return string(out)
 }
 
+// FormatRateStats formats the distribution of a rate on one line.
+func FormatRateStats(s monitor.RateStats) string {
+	return fmt.Sprintf("min %.1f  p50 %.1f  p95 %.1f  max %.1f  σ %.1f", s.Min, s.P50, s.P95, s.Max, s.StdDev)
+}
+
 // Ago formats a time as a human-readable duration since now.
 func Ago(t *time.Time) string {
 	if t == nil || (*t).IsZero() {
@@ -35,3 +44,17 @@ func Ago(t *time.Time) string {
 	}
 	return fmt.Sprintf("%s ago", time.Since(*t).Round(time.Second))
 }
+
+// barBlocks are the partial blocks drawn at the end of a bar, in eighths.
+var barBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// formatBar draws value as a horizontal bar of up to width cells, scaled to
+// maxValue and padded to the full width.
+func formatBar(value, maxValue float64, width int) string {
+	eighths := 0
+	if maxValue > 0 {
+		eighths = int(math.Round(min(max(value/maxValue, 0), 1) * float64(width*8)))
+	}
+	bar := strings.Repeat("█", eighths/8) + barBlocks[eighths%8]
+	return bar + strings.Repeat(" ", width-len([]rune(bar)))
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// maxPageDots is the most pages shown as dots in the status bar; more are
+// only counted.
+const maxPageDots = 10
+
+// cellGrid is the grid of consumer cells. It reports size changes so the
+// cells can be laid out for the terminal.
+type cellGrid struct {
+	*tview.Grid
+	width, height int
+	onResize      func()
+}
+
+func newCellGrid() *cellGrid {
+	return &cellGrid{Grid: tview.NewGrid()}
+}
+
+// Draw lays the cells out again if the size changed, then draws the grid.
+func (g *cellGrid) Draw(screen tcell.Screen) {
+	_, _, width, height := g.GetRect()
+	if width != g.width || height != g.height {
+		g.width, g.height = width, height
+		if g.onResize != nil {
+			g.onResize()
+		}
+	}
+	g.Grid.Draw(screen)
+}
+
+// gridShape returns the number of columns and the rows per page for n
+// cells in an area of the given size. Columns shrink below win.Columns to
+// keep cells at least MinCellWidth wide, and rows are limited so cells stay
+// MinCellHeight high. A zero size, before the first draw, is unlimited.
+func gridShape(n int, win config.WindowConfig, width, height int) (columns, rows int) {
+	columns = win.Columns
+	if columns <= 0 {
+		columns = 4
+	}
+	if width > 0 && win.MinCellWidth > 0 {
+		columns = min(columns, max(width/win.MinCellWidth, 1))
+	}
+	columns = max(min(columns, n), 1)
+
+	rows = (n + columns - 1) / columns
+	if height > 0 && win.MinCellHeight > 0 {
+		rows = min(rows, max(height/win.MinCellHeight, 1))
+	}
+	return columns, max(rows, 1)
+}
+
+// layoutGrid places the visible consumers of the current page in the grid,
+// row by row. With fewer consumers than columns, the cells share the full
+// width.
+func (p *WindowPanel) layoutGrid() {
+	if len(p.views) == 0 || p.views[0] == nil {
+		return // Not set up yet
+	}
+	p.grid.Clear()
+	if len(p.visible) == 0 {
+		p.page, p.pageCount = 0, 1
+		p.grid.SetRows(0).SetColumns(0)
+		p.grid.AddItem(p.empty, 0, 0, 1, 1, 0, 0, false)
+		return
+	}
+
+	columns, rows := gridShape(len(p.visible), p.config, p.grid.width, p.grid.height)
+	perPage := columns * rows
+	p.pageCount = (len(p.visible) + perPage - 1) / perPage
+	p.page = min(p.page, p.pageCount-1)
+	shown := p.visible[p.page*perPage : min(len(p.visible), (p.page+1)*perPage)]
+	if p.pageCount == 1 {
+		rows = (len(shown) + columns - 1) / columns
+	}
+	p.grid.SetRows(make([]int, rows)...).SetColumns(make([]int, columns)...) // 0 means equal distribution
+
+	for i, idx := range shown {
+		p.grid.AddItem(p.views[idx], i/columns, i%columns, 1, 1, 0, 0, false)
+	}
+}
+
+// turnPage moves delta pages through the grid, wrapping around. It reports
+// whether the window has more than one page.
+func (p *WindowPanel) turnPage(delta int) bool {
+	if p.view != config.ViewGrid || p.pageCount <= 1 {
+		return false
+	}
+	p.page = (p.page + delta + p.pageCount) % p.pageCount
+	p.layoutGrid()
+	return true
+}
+
+// reveal brings the consumer at idx into view: the grid turns to its page
+// and the table selects its row. It returns the primitive to focus to
+// highlight it, or nil.
+func (p *WindowPanel) reveal(idx int) tview.Primitive {
+	ref := p.config.Consumers[idx]
+	switch p.view {
+	case config.ViewGrid:
+		pos := slices.Index(p.visible, idx)
+		if pos < 0 || p.views[idx] == nil {
+			return nil
+		}
+		columns, rows := gridShape(len(p.visible), p.config, p.grid.width, p.grid.height)
+		p.page = pos / (columns * rows)
+		p.layoutGrid()
+		return p.views[idx]
+	case config.ViewTable:
+		if p.table.Select(ref) {
+			return p.table.view
+		}
+	}
+	return nil
+}
+
+// pageStatus describes the page of the grid for the status bar, or returns
+// "" if everything fits on one page.
+func (p *WindowPanel) pageStatus() string {
+	if p.view != config.ViewGrid || p.pageCount <= 1 {
+		return ""
+	}
+	text := paint(p.theme.Label, fmt.Sprintf("Page %d/%d", p.page+1, p.pageCount))
+	if p.pageCount <= maxPageDots {
+		text += " " + strings.Repeat("○", p.page) + "●" + strings.Repeat("○", p.pageCount-p.page-1)
+	}
+	return text
+}
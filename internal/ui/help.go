@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const helpWidth = 78
+
+// openHelp shows the key bindings of the key map in a dialog. Escape,
+// Enter or the help key close it.
+func (a *App) openHelp() {
+	text := a.keys.helpText(a.theme)
+	tv := tview.NewTextView().
+		SetDynamicColors(true).
+		SetScrollable(true).
+		SetText(text)
+	tv.SetBorder(true).SetTitle(" Keys (Esc to close) ")
+	tv.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter || a.keys.matches(event, actionHelp) {
+			a.closeDialog()
+			return nil
+		}
+		return event
+	})
+	a.openDialog(tv, helpWidth, strings.Count(text, "\n")+3)
+}
+
+// helpText lists the bound actions by group, followed by the keys that
+// can't be rebound.
+func (k *KeyMap) helpText(theme Theme) string {
+	const keyWidth = 18
+
+	var b strings.Builder
+	group := ""
+	for i := range k.actions {
+		act := &k.actions[i]
+		bound := k.bound[act]
+		if len(bound) == 0 {
+			continue
+		}
+		if act.group != group {
+			if group != "" {
+				b.WriteString("\n")
+			}
+			group = act.group
+			b.WriteString(paint(theme.Label, group) + "\n")
+		}
+		labels := make([]string, len(bound))
+		for j, key := range bound {
+			labels[j] = key.label()
+		}
+		fmt.Fprintf(&b, "  %s %s\n", paint(theme.Accent, tview.Escape(fmt.Sprintf("%-*s", keyWidth, strings.Join(labels, " ")))), act.help)
+	}
+	fmt.Fprintf(&b, "\n%s\n", paint(theme.Label, "Always"))
+	fmt.Fprintf(&b, "  %s Quit\n", paint(theme.Accent, fmt.Sprintf("%-*s", keyWidth, "Ctrl-C")))
+	fmt.Fprintf(&b, "  %s Copy the content of a cell", paint(theme.Accent, fmt.Sprintf("%-*s", keyWidth, "Double-click")))
+	return b.String()
+}
@@ -0,0 +1,296 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// Actions keys can be bound to, as named in the "keys" config.
+const (
+	actionHelp            = "help"
+	actionPalette         = "palette"
+	actionQuit            = "quit"
+	actionPrevWindow      = "prev_window"
+	actionNextWindow      = "next_window"
+	actionSelectWindow    = "select_window"
+	actionPrevPage        = "prev_page"
+	actionNextPage        = "next_page"
+	actionThroughput      = "throughput"
+	actionTimedThroughput = "timed_throughput"
+	actionClear           = "clear"
+	actionExport          = "export"
+	actionSaveBaseline    = "save_baseline"
+	actionChooseBaseline  = "choose_baseline"
+	actionViewMode        = "view_mode"
+	actionDebug           = "debug"
+	actionSkew            = "skew"
+	actionSortPrev        = "sort_prev"
+	actionSortNext        = "sort_next"
+	actionSortReverse     = "sort_reverse"
+	actionFilter          = "filter"
+	actionHideHealthy     = "hide_healthy"
+	actionClearFilter     = "clear_filter"
+)
+
+// keyAction is something a key does.
+type keyAction struct {
+	name  string
+	group string // Section of the help overlay
+	help  string
+	keys  []string // Default bindings
+
+	// run performs the action and reports whether it applied. A key whose
+	// action doesn't apply, such as sorting outside the table view, goes on
+	// to the next action bound to it and then to the focused view. n is the
+	// position of the key among the keys of the action.
+	run func(a *App, n int) bool
+}
+
+// keyActions are the actions in the order of the help overlay.
+var keyActions = []keyAction{
+	{actionHelp, "General", "Show the key bindings", []string{"?"}, always((*App).openHelp)},
+	{actionPalette, "General", "Open the command palette", []string{":"}, always((*App).openPalette)},
+	{actionQuit, "General", "Quit", []string{"q"}, always((*App).Stop)},
+	{actionPrevWindow, "Windows", "Previous window", []string{"<", ",", "Left"}, always((*App).prevWindow)},
+	{actionNextWindow, "Windows", "Next window", []string{">", ".", "Right"}, always((*App).nextWindow)},
+	{actionSelectWindow, "Windows", "Jump to the window of the tab, by position", []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, (*App).selectTab},
+	{actionPrevPage, "Windows", "Previous page of the grid", []string{"PgUp"}, func(a *App, _ int) bool { return a.turnPage(-1) }},
+	{actionNextPage, "Windows", "Next page of the grid", []string{"PgDn"}, func(a *App, _ int) bool { return a.turnPage(1) }},
+	{actionThroughput, "Throughput", "Start or stop a throughput measurement", []string{"t"}, always((*App).toggleThroughput)},
+	{actionTimedThroughput, "Throughput", "Measure throughput for throughput.duration, or stop", []string{"b"}, always((*App).timedThroughput)},
+	{actionClear, "Throughput", "Clear throughput results", []string{"c"}, always((*App).clearThroughput)},
+	{actionExport, "Throughput", "Export throughput results to a file", []string{"e"}, always((*App).exportThroughput)},
+	{actionSaveBaseline, "Throughput", "Save the results as a named baseline", []string{"s"}, always((*App).saveBaseline)},
+	{actionChooseBaseline, "Throughput", "Choose the baseline to compare with", []string{"v"}, always((*App).chooseBaseline)},
+	{actionViewMode, "Views", "Cycle the window through grid, table and compact", []string{"m"}, always((*App).toggleView)},
+	{actionDebug, "Views", "Show or hide the poll latency debug view", []string{"d"}, always((*App).toggleDebug)},
+	{actionSkew, "Views", "Show or hide the partition skew of the window", []string{"k"}, always((*App).toggleSkew)},
+	{actionSortPrev, "Table", "Sort by the previous column", []string{"["}, tableAction(func(t *ConsumerTable) { t.MoveSort(-1) })},
+	{actionSortNext, "Table", "Sort by the next column", []string{"]"}, tableAction(func(t *ConsumerTable) { t.MoveSort(1) })},
+	{actionSortReverse, "Table", "Reverse the sort order", []string{"r"}, tableAction((*ConsumerTable).ReverseSort)},
+	{actionFilter, "Filter", "Filter consumers by name and state", []string{"/"}, always((*App).openFilter)},
+	{actionHideHealthy, "Filter", "Hide or show healthy consumers", []string{"h"}, always((*App).toggleHealthy)},
+	{actionClearFilter, "Filter", "Clear the filter", []string{"Esc"}, func(a *App, _ int) bool { return a.clearFilter() }},
+}
+
+// always adapts an action that always applies.
+func always(f func(*App)) func(*App, int) bool {
+	return func(a *App, _ int) bool {
+		f(a)
+		return true
+	}
+}
+
+// tableAction adapts an action that applies to the table of the current
+// window while it is shown.
+func tableAction(f func(*ConsumerTable)) func(*App, int) bool {
+	return func(a *App, _ int) bool {
+		panel := a.panels[a.currentIdx]
+		if panel.view != config.ViewTable || a.debugShown || a.skewShown {
+			return false
+		}
+		f(panel.table)
+		return true
+	}
+}
+
+// contextualActions apply only in some views, so a key they share with
+// another action listed after them still reaches it.
+var contextualActions = map[string]bool{
+	actionPrevPage:    true,
+	actionNextPage:    true,
+	actionSortPrev:    true,
+	actionSortNext:    true,
+	actionSortReverse: true,
+	actionClearFilter: true,
+}
+
+// keyBinding is a key, or a character typed with one.
+type keyBinding struct {
+	key tcell.Key
+	ch  rune // For tcell.KeyRune
+}
+
+// keyNames maps the lower-case names of special keys, as tcell names them,
+// to the keys.
+var keyNames = func() map[string]tcell.Key {
+	names := map[string]tcell.Key{
+		"escape":   tcell.KeyEscape,
+		"pageup":   tcell.KeyPgUp,
+		"pagedown": tcell.KeyPgDn,
+	}
+	for key, name := range tcell.KeyNames {
+		if key != tcell.KeyRune {
+			names[strings.ToLower(name)] = key
+		}
+	}
+	return names
+}()
+
+// parseKey parses a single character, "Space" or the name of a special
+// key, such as "PgUp" or "Ctrl-R".
+func parseKey(s string) (keyBinding, error) {
+	if r := []rune(s); len(r) == 1 {
+		return keyBinding{key: tcell.KeyRune, ch: r[0]}, nil
+	}
+	if strings.EqualFold(s, "space") {
+		return keyBinding{key: tcell.KeyRune, ch: ' '}, nil
+	}
+	if key, ok := keyNames[strings.ToLower(s)]; ok {
+		return keyBinding{key: key}, nil
+	}
+	return keyBinding{}, fmt.Errorf("unknown key %q", s)
+}
+
+// eventBinding returns the binding a key event matches.
+func eventBinding(event *tcell.EventKey) keyBinding {
+	if event.Key() == tcell.KeyRune {
+		return keyBinding{key: tcell.KeyRune, ch: event.Rune()}
+	}
+	return keyBinding{key: event.Key()}
+}
+
+// name returns the name of the key, such as t or PgUp.
+func (b keyBinding) name() string {
+	return strings.Trim(b.label(), "'")
+}
+
+// label formats the key for hints: characters quoted, such as 't', and
+// special keys by name, such as PgUp.
+func (b keyBinding) label() string {
+	switch {
+	case b.key != tcell.KeyRune:
+		return tcell.KeyNames[b.key]
+	case b.ch == ' ':
+		return "Space"
+	default:
+		return "'" + string(b.ch) + "'"
+	}
+}
+
+// KeyMap binds keys to actions. Each action has default keys, which the
+// config can replace.
+type KeyMap struct {
+	actions []keyAction
+	byName  map[string]*keyAction
+	byKey   map[keyBinding][]*keyAction // In the order of keyActions
+	bound   map[*keyAction][]keyBinding
+}
+
+// NewKeyMap builds the key map from the default bindings and the bindings
+// configured by action. An action configured without keys is unbound. A key
+// may be bound to several actions only if all but the last of them, in the
+// order of keyActions, are contextual; otherwise the later ones would never
+// run.
+func NewKeyMap(keys map[string]config.KeyList) (*KeyMap, error) {
+	k := &KeyMap{
+		actions: slices.Clone(keyActions),
+		byName:  make(map[string]*keyAction),
+		byKey:   make(map[keyBinding][]*keyAction),
+		bound:   make(map[*keyAction][]keyBinding),
+	}
+	for i := range k.actions {
+		k.byName[k.actions[i].name] = &k.actions[i]
+	}
+
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		act, ok := k.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("keys: unknown action %q", name)
+		}
+		act.keys = keys[name]
+	}
+
+	for i := range k.actions {
+		act := &k.actions[i]
+		for _, s := range act.keys {
+			b, err := parseKey(s)
+			if err != nil {
+				return nil, fmt.Errorf("keys: %s: %w", act.name, err)
+			}
+			for _, other := range k.byKey[b] {
+				if !contextualActions[other.name] {
+					return nil, fmt.Errorf("keys: %s is bound to both %s and %s", b.name(), other.name, act.name)
+				}
+			}
+			k.byKey[b] = append(k.byKey[b], act)
+			k.bound[act] = append(k.bound[act], b)
+		}
+	}
+	return k, nil
+}
+
+// handle runs the first action bound to the key of event that applies. It
+// reports whether one did. Letters bound in lower case also match in upper
+// case, unless the upper-case letter has a binding of its own.
+func (k *KeyMap) handle(a *App, event *tcell.EventKey) bool {
+	b := eventBinding(event)
+	acts, ok := k.byKey[b]
+	if !ok && b.key == tcell.KeyRune && unicode.IsUpper(b.ch) {
+		b.ch = unicode.ToLower(b.ch)
+		acts = k.byKey[b]
+	}
+	for _, act := range acts {
+		if act.run(a, slices.Index(k.bound[act], b)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether event is bound to the action.
+func (k *KeyMap) matches(event *tcell.EventKey, action string) bool {
+	b := eventBinding(event)
+	lower := keyBinding{key: b.key, ch: unicode.ToLower(b.ch)}
+	bound := k.bound[k.byName[action]]
+	return slices.Contains(bound, b) || slices.Contains(bound, lower)
+}
+
+// hint describes actions in the status bar by their first keys, such as
+// "'<'/'>' windows". It returns "" if none of them is bound.
+func (k *KeyMap) hint(text string, actions ...string) string {
+	var keys []string
+	for _, name := range actions {
+		if bound := k.bound[k.byName[name]]; len(bound) > 0 {
+			keys = append(keys, bound[0].label())
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(strings.Join(keys, "/") + " " + text)
+}
+
+// nthKey returns the name of the key at position n among the keys of an
+// action, or "" if it has fewer.
+func (k *KeyMap) nthKey(action string, n int) string {
+	if bound := k.bound[k.byName[action]]; n < len(bound) {
+		return bound[n].name()
+	}
+	return ""
+}
+
+// joinHints joins the hints that are not empty with sep.
+func joinHints(sep string, hints ...string) string {
+	return strings.Join(slices.DeleteFunc(hints, func(h string) bool { return h == "" }), sep)
+}
+
+// withHint appends a hint in parentheses to a view title, such as
+// " Skew ('k' to close) ".
+func withHint(title, hint string) string {
+	if hint == "" {
+		return title
+	}
+	return title + "(" + hint + ") "
+}
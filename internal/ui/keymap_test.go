@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in    string
+		want  keyBinding
+		label string
+	}{
+		{"t", keyBinding{key: tcell.KeyRune, ch: 't'}, "'t'"},
+		{"T", keyBinding{key: tcell.KeyRune, ch: 'T'}, "'T'"},
+		{"?", keyBinding{key: tcell.KeyRune, ch: '?'}, "'?'"},
+		{"é", keyBinding{key: tcell.KeyRune, ch: 'é'}, "'é'"},
+		{"Space", keyBinding{key: tcell.KeyRune, ch: ' '}, "Space"},
+		{"space", keyBinding{key: tcell.KeyRune, ch: ' '}, "Space"},
+		{"PgUp", keyBinding{key: tcell.KeyPgUp}, "PgUp"},
+		{"PageDown", keyBinding{key: tcell.KeyPgDn}, "PgDn"},
+		{"Esc", keyBinding{key: tcell.KeyEscape}, "Esc"},
+		{"escape", keyBinding{key: tcell.KeyEscape}, "Esc"},
+		{"Enter", keyBinding{key: tcell.KeyEnter}, "Enter"},
+		{"F5", keyBinding{key: tcell.KeyF5}, "F5"},
+		{"Ctrl-R", keyBinding{key: tcell.KeyCtrlR}, "Ctrl-R"},
+		{"ctrl-r", keyBinding{key: tcell.KeyCtrlR}, "Ctrl-R"},
+	}
+	for _, tt := range tests {
+		got, err := parseKey(tt.in)
+		if err != nil {
+			t.Errorf("parseKey(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want || got.label() != tt.label {
+			t.Errorf("parseKey(%q) = %+v %s, want %+v %s", tt.in, got, got.label(), tt.want, tt.label)
+		}
+	}
+
+	for _, in := range []string{"", "tt", "Hyper-X", "Ctrl-"} {
+		if _, err := parseKey(in); err == nil {
+			t.Errorf("parseKey(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestNewKeyMap(t *testing.T) {
+	tests := []struct {
+		name string
+		keys map[string]config.KeyList
+		ok   bool
+	}{
+		{"defaults", nil, true},
+		{"rebound", map[string]config.KeyList{"quit": {"q", "Ctrl-Q"}, "next_page": {"Space"}}, true},
+		{"unbound", map[string]config.KeyList{"debug": {}}, true},
+		{"moved to a free key", map[string]config.KeyList{"throughput": {"T"}}, true},
+		{"unknown action", map[string]config.KeyList{"explode": {"x"}}, false},
+		{"unknown key", map[string]config.KeyList{"quit": {"Hyper-Q"}}, false},
+
+		// A contextual action may share its key with an action after it
+		{"after a table action", map[string]config.KeyList{"hide_healthy": {"r"}}, true},
+		{"after a page action", map[string]config.KeyList{"filter": {"PgUp"}}, true},
+
+		// Otherwise the second action could never run
+		{"before a table action", map[string]config.KeyList{"throughput": {"r"}}, false},
+		{"after select_window", map[string]config.KeyList{"hide_healthy": {"1"}}, false},
+		{"two plain actions", map[string]config.KeyList{"quit": {"t"}}, false},
+		{"twice in one action", map[string]config.KeyList{"quit": {"q", "q"}}, false},
+	}
+	for _, tt := range tests {
+		_, err := NewKeyMap(tt.keys)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: NewKeyMap(%v) error = %v, want ok %v", tt.name, tt.keys, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/alert"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+const (
+	noticeDuration   = 10 * time.Second
+	tmuxMessageDelay = "5000" // How long tmux shows the message, in milliseconds
+)
+
+// notice is a message shown in the status bar for a while.
+type notice struct {
+	text  string
+	color tcell.Color         // The error color if unset
+	ref   *config.ConsumerRef // Consumer the notice is about, nil for general notices
+	until time.Time
+}
+
+// terminalNotifier draws attention to firing alerts with the terminal bell,
+// desktop notification escape sequences or tmux. Each consumer notifies at
+// most once per rate limit so a flapping consumer doesn't spam.
+type terminalNotifier struct {
+	cfg  config.TerminalConfig
+	out  io.Writer
+	tmux bool // Running inside tmux
+	last map[string]time.Time
+}
+
+func newTerminalNotifier(cfg config.TerminalConfig, out io.Writer) *terminalNotifier {
+	return &terminalNotifier{
+		cfg:  cfg,
+		out:  out,
+		tmux: os.Getenv("TMUX") != "",
+		last: make(map[string]time.Time),
+	}
+}
+
+// notify sends the configured notifications for an event. It must run on
+// the UI goroutine, between draws, so the escape sequences don't land in
+// the middle of a screen update.
+func (n *terminalNotifier) notify(e alert.Event, now time.Time) {
+	if len(n.cfg.Methods) == 0 || e.Status != alert.Firing {
+		return
+	}
+	if len(n.cfg.Kinds) > 0 && !slices.Contains(n.cfg.Kinds, string(e.Kind)) {
+		return
+	}
+	key := e.Ref.Key()
+	if last, ok := n.last[key]; ok && now.Sub(last) < n.cfg.RateLimit.Duration() {
+		return
+	}
+	n.last[key] = now
+
+	msg := sanitize(fmt.Sprintf("%s %s: %s", key, e.Kind, e.Message))
+	for _, m := range n.cfg.Methods {
+		switch m {
+		case config.TerminalBell:
+			io.WriteString(n.out, "\a")
+		case config.TerminalOSC9:
+			io.WriteString(n.out, n.passthrough("\x1b]9;nmonitor: "+msg+"\a"))
+		case config.TerminalOSC777:
+			io.WriteString(n.out, n.passthrough("\x1b]777;notify;nmonitor;"+msg+"\a"))
+		case config.TerminalTmux:
+			n.tmuxAlert(msg)
+		}
+	}
+}
+
+// passthrough wraps an escape sequence so tmux forwards it to the outer
+// terminal. This needs "set -g allow-passthrough on" in tmux 3.3 and later.
+func (n *terminalNotifier) passthrough(seq string) string {
+	if !n.tmux {
+		return seq
+	}
+	return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+}
+
+// tmuxAlert rings the bell, which flags the window in the tmux status line
+// even when the pane isn't visible, and shows the message to the attached
+// clients. Outside tmux it does nothing.
+func (n *terminalNotifier) tmuxAlert(msg string) {
+	if !n.tmux {
+		return
+	}
+	io.WriteString(n.out, "\a")
+
+	args := []string{"display-message", "-d", tmuxMessageDelay}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	// tmux expands formats in the message, so escape '#'
+	args = append(args, "nmonitor: "+strings.ReplaceAll(msg, "#", "##"))
+	go func() {
+		_ = exec.Command("tmux", args...).Run()
+	}()
+}
+
+// sanitize strips control characters, which would end or corrupt an escape
+// sequence.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || (r >= 0x7f && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,492 @@
+package ui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+const (
+	paletteWidth   = 76
+	historySize    = 50 // Commands the palette remembers
+	maxCompletions = 10
+)
+
+// IntervalSetter changes how often consumers are polled; zero restores the
+// configured intervals.
+type IntervalSetter interface {
+	SetInterval(d time.Duration)
+}
+
+// SetPoller lets the command palette change the poll interval. It must be
+// called before Run.
+func (a *App) SetPoller(p IntervalSetter) {
+	a.poller = p
+}
+
+// paletteCommand is a command of the ':' palette.
+type paletteCommand struct {
+	name string
+	args string // Usage of the arguments, "" if there are none
+	help string
+
+	// complete lists the candidates for the argument, nil if it has none
+	complete func(a *App) []string
+	run      func(a *App, arg string) error
+}
+
+// paletteCommands are the commands with arguments. Every key action is a
+// command too, under its name.
+var paletteCommands = []paletteCommand{
+	{"window", "NAME|N", "Jump to a window by name or number", (*App).windowNames, (*App).jumpToWindow},
+	{"consumer", "STREAM/CONSUMER", "Jump to a consumer", (*App).consumerKeys, (*App).jumpToConsumer},
+	{"throughput", "[DURATION]", "Measure throughput for DURATION, or start or stop a measurement", nil, (*App).measureFor},
+	{"filter", "[QUERY]", "Filter consumers as with '/'; without a query, clear the filter", nil, (*App).filterBy},
+	{"view", "grid|table|compact", "Show the window in a view mode", func(*App) []string { return config.Views }, (*App).setView},
+	{"interval", "DURATION|reset", "Poll every consumer at DURATION, or as configured again", func(*App) []string { return []string{"reset"} }, (*App).setPollInterval},
+}
+
+// commands returns the commands of the palette: those with arguments, then
+// the key actions that don't share their name. The window command takes
+// the place of the numbered window keys.
+func (a *App) commands() []paletteCommand {
+	commands := slices.Clone(paletteCommands)
+	for i := range a.keys.actions {
+		act := &a.keys.actions[i]
+		if act.name == actionSelectWindow || slices.ContainsFunc(commands, func(c paletteCommand) bool { return c.name == act.name }) {
+			continue
+		}
+		commands = append(commands, paletteCommand{
+			name: act.name,
+			help: act.help,
+			run: func(a *App, arg string) error {
+				if arg != "" {
+					return fmt.Errorf("%s takes no arguments", act.name)
+				}
+				if !act.run(a, 0) {
+					return fmt.Errorf("%s: nothing to do here", act.name)
+				}
+				return nil
+			},
+		})
+	}
+	return commands
+}
+
+// openPalette asks for a command, completing command names and arguments
+// as they are typed. The line above the input shows the usage of the
+// command, or why it failed, in which case the palette stays open.
+func (a *App) openPalette() {
+	commands := a.commands()
+	input := tview.NewInputField().
+		SetLabel(":").
+		SetFieldWidth(0)
+	message := tview.NewTextView().SetDynamicColors(true)
+
+	// Up and Down browse the history while the input is empty or shows an
+	// entry of it; otherwise they move through the completions
+	browsing := len(a.history)
+	showUsage := func(text string) {
+		name, _, _ := strings.Cut(strings.TrimSpace(text), " ")
+		message.SetText(paint(a.theme.Muted, "Tab completes, ↑/↓ history"))
+		if c, ok := findCommand(commands, name); ok {
+			message.SetText(paint(a.theme.Muted, tview.Escape(strings.TrimSpace(c.name+" "+c.args)+" — "+c.help)))
+		}
+	}
+	showUsage("")
+
+	input.SetChangedFunc(func(text string) {
+		if browsing == len(a.history) || text != a.history[browsing] {
+			browsing = len(a.history)
+		}
+		showUsage(text)
+	})
+	input.SetAutocompleteFunc(func(text string) []string {
+		if browsing < len(a.history) {
+			return nil
+		}
+		return a.complete(commands, text)
+	})
+	input.SetAutocompletedFunc(func(text string, index, source int) bool {
+		if source == tview.AutocompletedNavigate {
+			return false
+		}
+		input.SetText(text)
+		if strings.HasSuffix(text, " ") {
+			a.app.QueueUpdateDraw(func() { input.Autocomplete() }) // Complete the argument
+		}
+		return true
+	})
+	input.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		text := input.GetText()
+		if text != "" && browsing == len(a.history) && event.Key() != tcell.KeyCtrlP && event.Key() != tcell.KeyCtrlN {
+			return event
+		}
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP:
+			browsing = max(browsing-1, 0)
+		case tcell.KeyDown, tcell.KeyCtrlN:
+			browsing = min(browsing+1, len(a.history))
+		default:
+			return event
+		}
+		if browsing < len(a.history) {
+			input.SetText(a.history[browsing])
+		} else {
+			input.SetText("")
+		}
+		return nil
+	})
+	input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+		case tcell.KeyTab, tcell.KeyBacktab:
+			a.app.QueueUpdateDraw(func() { input.Autocomplete() })
+			return
+		default:
+			a.closeDialog()
+			return
+		}
+		text := strings.TrimSpace(input.GetText())
+		if text == "" {
+			a.closeDialog()
+			return
+		}
+		a.remember(text)
+		browsing = len(a.history)
+
+		a.closeDialog()
+		if err := a.runCommand(commands, text); err != nil {
+			a.openDialog(a.paletteDialog(input, message), paletteWidth, 4)
+			message.SetText(paint(a.theme.ErrorText, "✗ "+tview.Escape(err.Error())))
+		}
+	})
+	a.openDialog(a.paletteDialog(input, message), paletteWidth, 4)
+}
+
+// paletteDialog lays out the palette. The message is above the input, so
+// the completions drop down over the window.
+func (a *App) paletteDialog(input *tview.InputField, message *tview.TextView) tview.Primitive {
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(message, 1, 0, false).
+		AddItem(input, 1, 0, true)
+	layout.SetBorder(true).SetTitle(" Command ")
+	return layout
+}
+
+// remember adds a command to the history, unless it repeats the last one.
+func (a *App) remember(text string) {
+	if n := len(a.history); n > 0 && a.history[n-1] == text {
+		return
+	}
+	a.history = append(a.history, text)
+	if len(a.history) > historySize {
+		a.history = a.history[len(a.history)-historySize:]
+	}
+}
+
+// runCommand runs a command line: a command name, which may be abbreviated
+// as long as it fuzzily matches one command best, then its argument.
+func (a *App) runCommand(commands []paletteCommand, text string) error {
+	name, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	c, ok := findCommand(commands, name)
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return c.run(a, strings.TrimSpace(arg))
+}
+
+// findCommand returns the command called name, or the best fuzzy match.
+func findCommand(commands []paletteCommand, name string) (paletteCommand, bool) {
+	if name == "" {
+		return paletteCommand{}, false
+	}
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		if strings.EqualFold(c.name, name) {
+			return c, true
+		}
+		names[i] = c.name
+	}
+	matches := fuzzyFilter(name, names)
+	if len(matches) == 0 {
+		return paletteCommand{}, false
+	}
+	i := slices.Index(names, matches[0])
+	return commands[i], true
+}
+
+// complete lists completions of a partial command line: command names
+// until a space is typed, then the candidates for the argument.
+func (a *App) complete(commands []paletteCommand, text string) []string {
+	name, arg, found := strings.Cut(strings.TrimLeft(text, " "), " ")
+	if name == "" {
+		return nil
+	}
+	if !found {
+		names := make([]string, len(commands))
+		for i, c := range commands {
+			names[i] = c.name
+		}
+		matches := fuzzyFilter(name, names)
+		entries := make([]string, 0, len(matches))
+		for _, m := range matches {
+			i := slices.Index(names, m)
+			if commands[i].args != "" {
+				m += " "
+			}
+			entries = append(entries, m)
+		}
+		return entries
+	}
+
+	c, ok := findCommand(commands, name)
+	if !ok || c.complete == nil {
+		return nil
+	}
+	candidates := c.complete(a)
+	if arg = strings.TrimSpace(arg); arg != "" {
+		candidates = fuzzyFilter(arg, candidates)
+	}
+	entries := make([]string, 0, min(len(candidates), maxCompletions))
+	for _, candidate := range candidates {
+		if len(entries) == maxCompletions {
+			break
+		}
+		entries = append(entries, c.name+" "+candidate)
+	}
+	return entries
+}
+
+// fuzzyFilter returns the candidates that contain the characters of
+// pattern in order, best matches first, ignoring case.
+func fuzzyFilter(pattern string, candidates []string) []string {
+	type match struct {
+		text  string
+		score int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if score, ok := fuzzyScore(pattern, c); ok {
+			matches = append(matches, match{c, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(len(a.text), len(b.text)))
+	})
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.text
+	}
+	return result
+}
+
+// fuzzyScore scores how well text matches pattern, favoring characters
+// that follow each other and start words. It reports false if text lacks
+// any of the characters in order.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	score, j, last := 0, 0, -2
+	for i := 0; i < len(t) && j < len(p); i++ {
+		if t[i] != p[j] {
+			continue
+		}
+		switch {
+		case i == last+1:
+			score += 3
+		case i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]):
+			score += 2
+		default:
+			score++
+		}
+		last = i
+		j++
+	}
+	if j < len(p) {
+		return 0, false
+	}
+	return score, true
+}
+
+// windowNames lists the windows for completion.
+func (a *App) windowNames() []string {
+	names := make([]string, len(a.panels))
+	for i, panel := range a.panels {
+		names[i] = panel.config.Name
+	}
+	return names
+}
+
+// consumerKeys lists every consumer of every window once for completion.
+func (a *App) consumerKeys() []string {
+	var keys []string
+	for _, panel := range a.panels {
+		for _, ref := range panel.config.Consumers {
+			if key := ref.Key(); !slices.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// jumpToWindow selects a window by number, name or best fuzzy match.
+func (a *App) jumpToWindow(arg string) error {
+	if arg == "" {
+		return errors.New("usage: window NAME|N")
+	}
+	names := a.windowNames()
+	if i := slices.IndexFunc(names, func(name string) bool { return strings.EqualFold(name, arg) }); i >= 0 {
+		a.SelectWindow(i)
+		return nil
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(a.panels) {
+			return fmt.Errorf("no window %d, there are %d", n, len(a.panels))
+		}
+		a.SelectWindow(n - 1)
+		return nil
+	}
+	matches := fuzzyFilter(arg, names)
+	if len(matches) == 0 {
+		return fmt.Errorf("no window matches %q", arg)
+	}
+	a.SelectWindow(slices.Index(names, matches[0]))
+	return nil
+}
+
+// jumpToConsumer shows the consumer that best matches arg, in the current
+// window if it has it, and selects it. A filter that hides it is cleared.
+func (a *App) jumpToConsumer(arg string) error {
+	if arg == "" {
+		return errors.New("usage: consumer STREAM/CONSUMER")
+	}
+	keys := a.consumerKeys()
+	matches := fuzzyFilter(arg, keys)
+	if len(matches) == 0 {
+		return fmt.Errorf("no consumer matches %q", arg)
+	}
+	key := matches[0]
+
+	idx := slices.IndexFunc(a.panels[a.currentIdx].config.Consumers, func(ref config.ConsumerRef) bool { return ref.Key() == key })
+	win := a.currentIdx
+	if idx < 0 {
+		for i, panel := range a.panels {
+			if idx = slices.IndexFunc(panel.config.Consumers, func(ref config.ConsumerRef) bool { return ref.Key() == key }); idx >= 0 {
+				win = i
+				break
+			}
+		}
+	}
+	panel := a.panels[win]
+	if !slices.Contains(panel.visible, idx) && panel.visible != nil {
+		a.setFilter(consumerFilter{})
+	}
+	a.SelectWindow(win)
+	if focus := panel.reveal(idx); focus != nil {
+		a.app.SetFocus(focus)
+	}
+	a.refreshStatus()
+	return nil
+}
+
+// measureFor measures throughput for a duration such as "30s" or a number
+// of seconds, restarting a running measurement. Without a duration it
+// starts or stops one like the throughput key.
+func (a *App) measureFor(arg string) error {
+	if arg == "" {
+		a.toggleThroughput()
+		return nil
+	}
+	d, err := parseCommandDuration(arg)
+	if err != nil {
+		return err
+	}
+	if a.lastStates == nil {
+		return errors.New("no consumer states yet")
+	}
+	for _, panel := range a.panels {
+		panel.throughput.Stop()
+		panel.throughput.StartFor(a.lastStates, d)
+	}
+	a.refreshStatus()
+	return nil
+}
+
+// filterBy applies a filter query, keeping whether healthy consumers are
+// hidden, or clears the filter without a query.
+func (a *App) filterBy(arg string) error {
+	if arg == "" {
+		a.clearFilter()
+		return nil
+	}
+	f, err := parseFilter(arg)
+	if err != nil {
+		return err
+	}
+	f.hideHealthy = a.filter.hideHealthy
+	a.setFilter(f)
+	a.jumpToMatch()
+	return nil
+}
+
+// setView switches the current window to a view mode.
+func (a *App) setView(arg string) error {
+	if !slices.Contains(config.Views, arg) {
+		return fmt.Errorf("unknown view %q (want %s)", arg, strings.Join(config.Views, ", "))
+	}
+	if a.debugShown || a.skewShown {
+		a.showWindow()
+	}
+	a.panels[a.currentIdx].setView(arg)
+	a.app.SetFocus(a.pages)
+	a.refreshStatus()
+	return nil
+}
+
+// setPollInterval changes how often every consumer is polled.
+func (a *App) setPollInterval(arg string) error {
+	if a.poller == nil {
+		return errors.New("the poll interval can't be changed")
+	}
+	if arg == "reset" {
+		a.poller.SetInterval(0)
+		a.showNotice("Polling at the configured intervals", a.theme.OK)
+		return nil
+	}
+	d, err := parseCommandDuration(arg)
+	if err != nil {
+		return err
+	}
+	a.poller.SetInterval(d)
+	a.showNotice(fmt.Sprintf("Polling every consumer every %s", d), a.theme.OK)
+	return nil
+}
+
+// parseCommandDuration parses a positive duration such as "30s", or a
+// number of seconds.
+func parseCommandDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		secs, serr := strconv.ParseFloat(s, 64)
+		if serr != nil {
+			return 0, fmt.Errorf("invalid duration %q, want e.g. 30s or 2m", s)
+		}
+		d = time.Duration(secs * float64(time.Second))
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,54 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, text string
+		score         int
+		ok            bool
+	}{
+		{"", "window", 0, true},
+		{"win", "window", 2 + 3 + 3, true},        // Word start, then consecutive
+		{"WIN", "window", 2 + 3 + 3, true},        // Case is ignored
+		{"wd", "window", 2 + 1, true},             // Gap
+		{"sh", "sort_hide", 2 + 2, true},          // Both start words
+		{"tt", "timed_throughput", 2 + 2, true},   // Second t starts a word
+		{"ow", "window", 1 + 3, true},             // Inside a word
+		{"wx", "window", 0, false},                // Missing character
+		{"nw", "window", 1 + 1, true},             // Matched in order, skipping the first w
+		{"od", "window", 0, false},                // Out of order
+		{"window", "win", 0, false},               // Longer than the text
+		{"s/c", "ORDERS/worker", 0, false},        // No c after the slash
+		{"o/w", "ORDERS/worker", 2 + 1 + 3, true}, // The slash ends a word
+	}
+	for _, tt := range tests {
+		score, ok := fuzzyScore(tt.pattern, tt.text)
+		if ok != tt.ok || ok && score != tt.score {
+			t.Errorf("fuzzyScore(%q, %q) = %d, %v, want %d, %v", tt.pattern, tt.text, score, ok, tt.score, tt.ok)
+		}
+	}
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []string{"throughput", "timed_throughput", "toggle", "filter", "interval"}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		// Better scores first, then shorter candidates
+		{"th", []string{"throughput", "timed_throughput"}},
+		{"t", []string{"toggle", "throughput", "timed_throughput", "filter", "interval"}},
+		{"tt", []string{"timed_throughput", "throughput"}},
+		{"fil", []string{"filter"}},
+		{"xyz", []string{}},
+	}
+	for _, tt := range tests {
+		if got := fuzzyFilter(tt.pattern, candidates); !slices.Equal(got, tt.want) {
+			t.Errorf("fuzzyFilter(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+	"github.com/jrlangford/nats-consumer-monitor/internal/skew"
+)
+
+const (
+	skewPage     = "skew"
+	skewBarWidth = 40
+)
+
+// SkewView compares the consumers of a window side by side, so a partition
+// that lags behind its siblings stands out.
+type SkewView struct {
+	view  *tview.TextView
+	theme Theme
+	hint  string // Key to close the view, for the title
+}
+
+// NewSkewView creates the skew view.
+func NewSkewView(theme Theme) *SkewView {
+	tv := tview.NewTextView()
+	tv.SetDynamicColors(true)
+	tv.SetScrollable(true)
+	tv.SetBackgroundColor(theme.Background)
+	tv.SetBorder(true)
+	tv.SetBorderColor(theme.Border)
+	return &SkewView{view: tv, theme: theme}
+}
+
+// Render draws the consumers of win from states. It must be called from the
+// UI goroutine.
+func (s *SkewView) Render(win config.WindowConfig, states []monitor.ConsumerState) {
+	byKey := make(map[string]monitor.ConsumerState, len(states))
+	for _, state := range states {
+		byKey[state.Ref.Key()] = state
+	}
+	metrics := skew.Analyze(win.Consumers, byKey)
+
+	s.view.SetTitle(withHint(fmt.Sprintf(" Skew: %s ", tview.Escape(win.Name)), s.hint))
+
+	width := 0
+	for _, ref := range win.Consumers {
+		width = max(width, len(ref.Consumer))
+	}
+
+	var b strings.Builder
+	t := s.theme
+	b.WriteString(paint(t.Muted, fmt.Sprintf("Outliers have a modified z-score above %.1f against the window median.", skew.OutlierScore)) + "\n")
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "\n%s  median %s  MAD %s", paint(t.Label, m.Name), formatSkewValue(m.Median, m.Unit), formatSkewValue(m.MAD, m.Unit))
+		if n := m.Outliers(); n > 0 {
+			b.WriteString("  " + paint(t.ErrorText, fmt.Sprintf("%d outlier(s)", n)))
+		}
+		b.WriteString("\n")
+		if len(m.Values) == 0 {
+			b.WriteString(paint(t.Muted, "No data yet") + "\n")
+			continue
+		}
+		for _, v := range m.Values {
+			line := fmt.Sprintf("%-*s %s %12s  z %+6.1f", width, v.Ref.Consumer, formatBar(v.Value, m.Max, skewBarWidth), formatSkewValue(v.Value, m.Unit), v.Score)
+			if v.Outlier {
+				line = paint(t.ErrorText, tview.Escape(line))
+			} else {
+				line = tview.Escape(line)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	s.view.SetText(b.String())
+}
+
+func formatSkewValue(v float64, unit string) string {
+	if unit == "" {
+		return FormatInt(uint64(math.Round(v)))
+	}
+	return fmt.Sprintf("%.1f%s", v, unit)
+}
@@ -0,0 +1,190 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/alert"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+)
+
+// SetNotifications configures the terminal notifications for alerts. It
+// must be called before Run.
+func (a *App) SetNotifications(cfg config.TerminalConfig) {
+	a.notifier.cfg = cfg
+}
+
+// Notify implements alert.Notifier. Firing alerts on consumers that are not
+// visible are announced in the status bar of the current window.
+func (a *App) Notify(events []alert.Event) {
+	a.app.QueueUpdateDraw(func() {
+		now := time.Now()
+		for _, e := range events {
+			if e.Status == alert.Resolved {
+				delete(a.alerts, e.Key())
+				continue
+			}
+			a.alerts[e.Key()] = e
+			a.notifier.notify(e, now)
+
+			ref := e.Ref
+			a.notice = notice{
+				text:  fmt.Sprintf("⚠ %s %s: %s", ref.Key(), e.Kind, e.Message),
+				ref:   &ref,
+				until: now.Add(noticeDuration),
+			}
+		}
+		a.refreshStatus()
+	})
+}
+
+// ReportError shows a background error, such as a failed webhook, in the
+// status bar for a while.
+func (a *App) ReportError(err error) {
+	text, _, _ := strings.Cut(err.Error(), "\n")
+	a.app.QueueUpdateDraw(func() {
+		a.showNotice("✗ "+text, a.theme.ErrorText)
+	})
+}
+
+// showNotice shows a message in the status bar of every window for a while.
+// It must run on the UI goroutine.
+func (a *App) showNotice(text string, color tcell.Color) {
+	a.notice = notice{text: text, color: color, until: time.Now().Add(noticeDuration)}
+	a.refreshStatus()
+}
+
+// refreshStatus redraws the status bar of every window and the tab bar. It
+// must run on the UI goroutine.
+func (a *App) refreshStatus() {
+	for i, panel := range a.panels {
+		panel.statusBar.SetText(a.statusText(i))
+	}
+	a.refreshTabs()
+}
+
+// statusText builds the status bar of a window: the filter, the page, the
+// throughput state, alerts on other windows and poll statistics. The tab
+// bar names the window.
+func (a *App) statusText(idx int) string {
+	panel := a.panels[idx]
+	t := a.theme
+	var parts []string
+	if filter := a.filterStatus(panel); filter != "" {
+		parts = append(parts, filter)
+	}
+	if page := panel.pageStatus(); page != "" {
+		parts = append(parts, joinHints(" ", page, a.keys.hint("", actionPrevPage, actionNextPage)))
+	}
+
+	switch {
+	case panel.throughput.IsMeasuring():
+		text := paint(t.OK, "▶ Measuring...")
+		if deadline := panel.throughput.Deadline(); !deadline.IsZero() {
+			left := max(time.Until(deadline).Round(time.Second), 0)
+			text = paint(t.OK, fmt.Sprintf("▶ Measuring... %s left", left))
+		}
+		parts = append(parts, joinHints(" ", text, a.keys.hint("to stop", actionThroughput)))
+	case panel.hasThroughputResults():
+		parts = append(parts, paint(t.WarningText, "■ Done")+" "+joinHints(" | ",
+			a.keys.hint("restart", actionThroughput),
+			a.keys.hint("clear", actionClear),
+			a.keys.hint("windows", actionPrevWindow, actionNextWindow),
+			"double-click to copy"))
+	default:
+		parts = append(parts, paint(t.Muted, a.statusHints()))
+	}
+
+	if elsewhere := a.alertsElsewhere(idx); elsewhere != "" {
+		parts = append(parts, paint(t.ErrorText, elsewhere))
+	}
+	if n := a.notice; n.text != "" && time.Now().Before(n.until) && (n.ref == nil || !panel.contains(*n.ref)) {
+		color := cmp.Or(n.color, t.ErrorText)
+		parts = append(parts, "["+colorName(color)+"::b]"+tview.Escape(n.text)+"[-::-]")
+	}
+
+	if a.stats != nil {
+		parts = append(parts, paint(t.Muted, fmt.Sprintf("%d polled, %d API calls", a.stats.Polled, a.stats.APICalls)))
+	}
+	return strings.Join(parts, " "+paint(t.Muted, "|")+" ")
+}
+
+// statusHints lists the main keys for the status bar.
+func (a *App) statusHints() string {
+	k := a.keys
+	return joinHints(" | ",
+		k.hint("throughput", actionThroughput),
+		k.hint("timed throughput", actionTimedThroughput),
+		k.hint("clear", actionClear),
+		k.hint("export", actionExport),
+		k.hint("baselines", actionSaveBaseline, actionChooseBaseline),
+		k.hint("windows", actionPrevWindow, actionNextWindow),
+		k.hint("debug", actionDebug),
+		k.hint("skew", actionSkew),
+		k.hint("view mode", actionViewMode),
+		k.hint("filter", actionFilter),
+		k.hint("hide healthy", actionHideHealthy),
+		k.hint("commands", actionPalette),
+		k.hint("help", actionHelp),
+		joinHints("/", k.hint("", actionQuit), "Ctrl-C")+" quit",
+		"double-click to copy")
+}
+
+// alertsElsewhere summarizes the firing alerts of consumers that are not in
+// the window at idx, by the windows that show them.
+func (a *App) alertsElsewhere(idx int) string {
+	counts := make(map[int]int)
+	for _, e := range a.alerts {
+		if a.panels[idx].contains(e.Ref) {
+			continue
+		}
+		for i, panel := range a.panels {
+			if panel.contains(e.Ref) {
+				counts[i]++
+			}
+		}
+	}
+	if len(counts) == 0 {
+		return ""
+	}
+
+	windows := make([]int, 0, len(counts))
+	total := 0
+	for i, n := range counts {
+		windows = append(windows, i)
+		total += n
+	}
+	slices.SortFunc(windows, cmp.Compare[int])
+
+	names := make([]string, len(windows))
+	for j, i := range windows {
+		names[j] = fmt.Sprintf("%s (%d)", tview.Escape(a.panels[i].config.Name), counts[i])
+	}
+	noun := "alerts"
+	if total == 1 {
+		noun = "alert"
+	}
+	return fmt.Sprintf("⚠ %d %s on %s", total, noun, strings.Join(names, ", "))
+}
+
+// contains reports whether the window shows a consumer.
+func (p *WindowPanel) contains(ref config.ConsumerRef) bool {
+	return slices.Contains(p.config.Consumers, ref)
+}
+
+// hasThroughputResults reports whether any consumer of the window has a
+// throughput measurement.
+func (p *WindowPanel) hasThroughputResults() bool {
+	for _, ref := range p.config.Consumers {
+		if p.throughput.Get(ref.Stream, ref.Consumer) != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// updateSummary refreshes the totals row of the window.
+func (p *WindowPanel) updateSummary(app *tview.Application, states []monitor.ConsumerState) {
+	byKey := make(map[string]monitor.ConsumerState, len(states))
+	for _, state := range states {
+		byKey[state.Ref.Key()] = state
+	}
+	all := p.throughput.All()
+	summary := monitor.Summarize(p.config.Consumers, byKey, all)
+	text := formatSummary(p.theme, summary)
+	if b := p.baseline.Load(); b != nil && summary.Measured > 0 {
+		if wc := b.CompareWindow(p.config, all, p.threshold); len(wc.Consumers) > 0 {
+			text += "  " + formatComparison(p.theme, b.Name, wc.Total)
+		}
+	}
+
+	app.QueueUpdateDraw(func() {
+		p.summary.SetText(text)
+	})
+}
+
+// formatSummary renders the totals of a window: continuous rates and
+// backlog on the first line, the throughput measurement on the second.
+func formatSummary(theme Theme, s monitor.WindowSummary) string {
+	var b strings.Builder
+	label := tag(theme.Label)
+	fmt.Fprintf(&b, " %sΣ %d consumers:[-] %.1f/s delivered, %.1f/s acked  %sUnprocessed:[-] %s  %sOutstanding Acks:[-] %s",
+		label, s.Consumers, s.Rates.Delivered, s.Rates.Acked, label, FormatInt(s.Pending), label, FormatInt(uint64(s.AckPending)))
+	b.WriteString(formatPartitions(theme.Label, s.Rates))
+	if s.Errors > 0 {
+		b.WriteString("  " + paint(theme.ErrorText, fmt.Sprintf("%d failing", s.Errors)))
+	}
+
+	if s.Measured > 0 {
+		fmt.Fprintf(&b, "\n %sΣ Throughput:[-] %s msgs (%.1f/s) delivered, %s msgs (%.1f/s) acked",
+			tag(theme.Accent), FormatInt(s.Delivered), s.Throughput.Delivered, FormatInt(s.Acked), s.Throughput.Acked)
+		b.WriteString(formatPartitions(theme.Accent, s.Throughput))
+	}
+	return b.String()
+}
+
+// formatPartitions names the slowest and fastest consumer by acked rate.
+func formatPartitions(color tcell.Color, t monitor.RateTotals) string {
+	if t.Slowest == nil || t.Fastest == nil {
+		return ""
+	}
+	return fmt.Sprintf("  %sSlowest:[-] %s (%.1f/s)  %sFastest:[-] %s (%.1f/s)",
+		tag(color), tview.Escape(t.Slowest.Ref.Consumer), t.Slowest.AckedRate,
+		tag(color), tview.Escape(t.Fastest.Ref.Consumer), t.Fastest.AckedRate)
+}
@@ -0,0 +1,299 @@
+package ui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// tableColumn is one column of the consumer table.
+type tableColumn struct {
+	title  string
+	number bool // Right-aligned and sorted numerically
+	value  func(monitor.ConsumerState) float64
+	text   func(monitor.ConsumerState) string
+}
+
+var tableColumns = []tableColumn{
+	{title: "Consumer", text: func(s monitor.ConsumerState) string { return s.Ref.Consumer }},
+	{title: "Stream", text: func(s monitor.ConsumerState) string { return s.Ref.Stream }},
+	{title: "Delivered", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.DeliveredConsumer) }},
+	{title: "Ack Floor", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.AckConsumer) }},
+	{title: "Ack Floor Stream", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.AckStream) }},
+	{title: "Outstanding Acks", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumAckPending) }},
+	{title: "Redelivered", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumRedelivered) }},
+	{title: "Unprocessed", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumPending) }},
+	{title: "Waiting Pulls", number: true, value: func(s monitor.ConsumerState) float64 { return float64(s.Snapshot.NumWaiting) }},
+	{title: "Delivered/s", number: true, value: func(s monitor.ConsumerState) float64 { return s.Rates.Delivered }},
+	{title: "Acked/s", number: true, value: func(s monitor.ConsumerState) float64 { return s.Rates.Acked }},
+}
+
+// cellText formats the value of a column for one consumer.
+func (c tableColumn) cellText(s monitor.ConsumerState) string {
+	switch {
+	case c.text != nil:
+		return c.text(s)
+	case strings.HasSuffix(c.title, "/s"):
+		return fmt.Sprintf("%.1f", c.value(s))
+	default:
+		return FormatInt(uint64(c.value(s)))
+	}
+}
+
+// compare orders two consumers by the column. Consumers with an error sort
+// after the others on numeric columns in either order.
+func (c tableColumn) compare(a, b monitor.ConsumerState, desc bool) int {
+	var n int
+	switch {
+	case c.text != nil:
+		n = cmp.Compare(c.text(a), c.text(b))
+	case (a.Error != nil) != (b.Error != nil):
+		if a.Error != nil {
+			return 1
+		}
+		return -1
+	default:
+		n = cmp.Compare(c.value(a), c.value(b))
+	}
+	if desc {
+		return -n
+	}
+	return n
+}
+
+// ConsumerTable shows the consumers of a window as a sortable table with
+// one row per consumer. Cells whose value changed flash individually.
+type ConsumerTable struct {
+	view       *tview.Table
+	theme      Theme
+	refs       []config.ConsumerRef
+	listed     map[string]bool
+	visible    map[string]bool // Consumers shown; nil shows all
+	states     map[string]monitor.ConsumerState
+	texts      map[string][]string // Last cell texts by consumer key
+	flashUntil map[string][]time.Time
+	sortCol    int
+	desc       bool
+	hint       string   // Sort keys for the title
+	rows       []string // Keys of the rows in display order
+}
+
+// NewConsumerTable creates the table for the consumers of refs.
+func NewConsumerTable(refs []config.ConsumerRef, theme Theme) *ConsumerTable {
+	tv := tview.NewTable().
+		SetFixed(1, 1).
+		SetSelectable(true, false)
+	tv.SetBackgroundColor(theme.Background)
+	tv.SetBorder(true)
+	tv.SetBorderColor(theme.Border)
+
+	t := &ConsumerTable{
+		view:       tv,
+		theme:      theme,
+		refs:       refs,
+		listed:     make(map[string]bool),
+		states:     make(map[string]monitor.ConsumerState),
+		texts:      make(map[string][]string),
+		flashUntil: make(map[string][]time.Time),
+	}
+	for _, ref := range refs {
+		t.listed[ref.Key()] = true
+	}
+	tv.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick {
+			return action, event
+		}
+		row, col := tv.CellAt(event.Position())
+		if row != 0 || col < 0 {
+			return action, event
+		}
+		t.SortBy(col)
+		return tview.MouseConsumed, nil
+	})
+	t.render(time.Now())
+	return t
+}
+
+// Update records new consumer states and marks the cells that changed.
+// It returns when the last flash ends, or the zero time if nothing changed.
+// It must be called from the UI goroutine.
+func (t *ConsumerTable) Update(states []monitor.ConsumerState, now time.Time) time.Time {
+	var until time.Time
+	for _, state := range states {
+		key := state.Ref.Key()
+		if !t.listed[key] {
+			continue
+		}
+		t.states[key] = state
+
+		texts := make([]string, len(tableColumns))
+		for i, col := range tableColumns {
+			texts[i] = col.cellText(state)
+		}
+		prev, seen := t.texts[key]
+		flashes := t.flashUntil[key]
+		if flashes == nil {
+			flashes = make([]time.Time, len(tableColumns))
+			t.flashUntil[key] = flashes
+		}
+		for i := range texts {
+			if seen && state.Error == nil && texts[i] != prev[i] {
+				flashes[i] = now.Add(flashDuration)
+				until = flashes[i]
+			}
+		}
+		t.texts[key] = texts
+	}
+	t.render(now)
+	return until
+}
+
+// SetVisible shows only the consumers whose keys are in visible, or all of
+// them if visible is nil.
+func (t *ConsumerTable) SetVisible(visible map[string]bool) {
+	t.visible = visible
+	t.render(time.Now())
+}
+
+// Refresh redraws the table, ending flashes that are over.
+func (t *ConsumerTable) Refresh(now time.Time) {
+	t.render(now)
+}
+
+// SortBy sorts by the column at idx. Sorting by the current column again
+// reverses the order.
+func (t *ConsumerTable) SortBy(idx int) {
+	if idx < 0 || idx >= len(tableColumns) {
+		return
+	}
+	if idx == t.sortCol {
+		t.desc = !t.desc
+	} else {
+		t.sortCol = idx
+		t.desc = false
+	}
+	t.render(time.Now())
+}
+
+// MoveSort sorts by the column delta columns away from the current one.
+func (t *ConsumerTable) MoveSort(delta int) {
+	t.sortCol = (t.sortCol + delta + len(tableColumns)) % len(tableColumns)
+	t.render(time.Now())
+}
+
+// ReverseSort flips the sort order.
+func (t *ConsumerTable) ReverseSort() {
+	t.desc = !t.desc
+	t.render(time.Now())
+}
+
+// Select selects the row of a consumer. It reports whether the table shows
+// the consumer.
+func (t *ConsumerTable) Select(ref config.ConsumerRef) bool {
+	r := slices.Index(t.rows, ref.Key())
+	if r < 0 {
+		return false
+	}
+	t.view.Select(r+1, 0)
+	return true
+}
+
+func (t *ConsumerTable) render(now time.Time) {
+	col := tableColumns[t.sortCol]
+	t.view.SetTitle(withHint(fmt.Sprintf(" Sorted by %s %s ", col.title, t.arrow()), t.hint))
+
+	for i, c := range tableColumns {
+		title := c.title
+		if i == t.sortCol {
+			title += " " + t.arrow()
+		}
+		cell := tview.NewTableCell(tview.Escape(title)).
+			SetTextColor(t.theme.Label).
+			SetBackgroundColor(t.theme.Background).
+			SetSelectable(false)
+		if c.number {
+			cell.SetAlign(tview.AlignRight)
+		}
+		t.view.SetCell(0, i, cell)
+	}
+
+	rows := make([]monitor.ConsumerState, 0, len(t.states))
+	seen := make(map[string]bool, len(t.states))
+	for _, ref := range t.refs {
+		key := ref.Key()
+		if t.visible != nil && !t.visible[key] {
+			continue
+		}
+		if state, ok := t.states[key]; ok && !seen[key] {
+			seen[key] = true
+			rows = append(rows, state)
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b monitor.ConsumerState) int {
+		return col.compare(a, b, t.desc)
+	})
+
+	t.rows = t.rows[:0]
+	for r, state := range rows {
+		key := state.Ref.Key()
+		t.rows = append(t.rows, key)
+		for i, c := range tableColumns {
+			text := t.texts[key][i]
+			color := t.theme.Text
+			if state.Error != nil && c.text == nil {
+				text, color = "", t.theme.ErrorText
+				if i == 2 { // First numeric column
+					text = "ERROR"
+				}
+			}
+			bg := t.theme.Background
+			if now.Before(t.flashUntil[key][i]) {
+				bg = t.theme.Flash
+			}
+			cell := tview.NewTableCell(tview.Escape(text)).
+				SetTextColor(color).
+				SetBackgroundColor(bg)
+			if c.number {
+				cell.SetAlign(tview.AlignRight)
+			}
+			if state.Error != nil && i == 0 {
+				cell.SetTextColor(t.theme.ErrorText)
+			}
+			t.view.SetCell(r+1, i, cell)
+		}
+	}
+	for t.view.GetRowCount() > len(rows)+1 {
+		t.view.RemoveRow(t.view.GetRowCount() - 1)
+	}
+}
+
+func (t *ConsumerTable) arrow() string {
+	if t.desc {
+		return "▼"
+	}
+	return "▲"
+}
+
+// updateTable passes new states to the table and redraws it when the
+// flashes of changed cells end.
+func (p *WindowPanel) updateTable(app *tview.Application, states []monitor.ConsumerState) {
+	app.QueueUpdateDraw(func() {
+		until := p.table.Update(states, time.Now())
+		if until.IsZero() {
+			return
+		}
+		time.AfterFunc(time.Until(until), func() {
+			app.QueueUpdateDraw(func() {
+				p.table.Refresh(time.Now())
+			})
+		})
+	})
+}
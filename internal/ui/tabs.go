@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// tabBar lists the windows above them. Clicking a tab selects its window.
+type tabBar struct {
+	*tview.TextView
+	ends     []int // Column after each tab
+	onSelect func(idx int)
+}
+
+func newTabBar(theme Theme) *tabBar {
+	tv := tview.NewTextView()
+	tv.SetDynamicColors(true)
+	tv.SetWrap(false)
+	tv.SetBackgroundColor(theme.Background)
+
+	t := &tabBar{TextView: tv}
+	tv.SetMouseCapture(func(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+		if action != tview.MouseLeftClick {
+			return action, event
+		}
+		x, _ := event.Position()
+		left, _, _, _ := tv.GetInnerRect()
+		for i, end := range t.ends {
+			if x-left < end {
+				if t.onSelect != nil {
+					t.onSelect(i)
+				}
+				break
+			}
+		}
+		return tview.MouseConsumed, nil
+	})
+	return t
+}
+
+// setTabs shows the tabs, given as text with color tags.
+func (t *tabBar) setTabs(tabs []string) {
+	t.ends = t.ends[:0]
+	end := 0
+	for _, tab := range tabs {
+		end += tview.TaggedStringWidth(tab)
+		t.ends = append(t.ends, end)
+		end++ // Space between tabs
+	}
+	t.SetText(strings.Join(tabs, " "))
+}
+
+// windowProblems counts the consumers of a window that fail to fetch, are
+// stalled and have a firing alert.
+type windowProblems struct {
+	errors, stalled, alerting int
+}
+
+func (w windowProblems) any() bool {
+	return w.errors+w.stalled+w.alerting > 0
+}
+
+// problems counts the problems of the window at idx in states.
+func (a *App) problems(idx int, states map[string]monitor.ConsumerState, now time.Time) windowProblems {
+	var w windowProblems
+	seen := make(map[string]bool)
+	for _, ref := range a.panels[idx].config.Consumers {
+		key := ref.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if state, ok := states[key]; ok {
+			if state.Error != nil {
+				w.errors++
+			} else if a.stalled(state, now) {
+				w.stalled++
+			}
+		}
+	}
+	alerting := make(map[string]bool)
+	for _, e := range a.alerts {
+		if seen[e.Ref.Key()] {
+			alerting[e.Ref.Key()] = true
+		}
+	}
+	w.alerting = len(alerting)
+	return w
+}
+
+// refreshTabs redraws the tab bar: the number key and name of each window
+// with badges counting its failing (✗), stalled (■) and alerting (⚠)
+// consumers. Windows with problems that are not shown stand out in the
+// error color. It must run on the UI goroutine.
+func (a *App) refreshTabs() {
+	if a.tabs == nil {
+		return
+	}
+	states := make(map[string]monitor.ConsumerState, len(a.filterStates))
+	for _, state := range a.filterStates {
+		states[state.Ref.Key()] = state
+	}
+
+	now := time.Now()
+	t := a.theme
+	tabs := make([]string, len(a.panels))
+	for i, panel := range a.panels {
+		w := a.problems(i, states, now)
+		label := tview.Escape(panel.config.Name)
+		if key := a.keys.nthKey(actionSelectWindow, i); key != "" {
+			label = tview.Escape(key) + " " + label
+		}
+
+		var badges []string
+		if w.errors > 0 {
+			badges = append(badges, fmt.Sprintf("✗%d", w.errors))
+		}
+		if w.stalled > 0 {
+			badges = append(badges, fmt.Sprintf("■%d", w.stalled))
+		}
+		if w.alerting > 0 {
+			badges = append(badges, fmt.Sprintf("⚠%d", w.alerting))
+		}
+		text := " " + strings.Join(append([]string{label}, badges...), " ") + " "
+
+		switch {
+		case i == a.currentIdx:
+			tabs[i] = badge(t.Flash, t.Text, text)
+			if w.any() {
+				tabs[i] = badge(t.Flash, t.ErrorText, text)
+			}
+		case w.any():
+			tabs[i] = badge(t.ErrorText, t.Background, text)
+		default:
+			tabs[i] = paint(t.Muted, text)
+		}
+	}
+	a.tabs.setTabs(tabs)
+}
+
+// selectTab selects the window at position n of the tab bar.
+func (a *App) selectTab(n int) bool {
+	if n < 0 || n >= len(a.panels) {
+		return false
+	}
+	a.SelectWindow(n)
+	return true
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rivo/tview"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// defaultCellTemplate is the built-in layout of a grid cell.
+const defaultCellTemplate = `{{if .Error -}}
+{{color "error" "ERROR"}}
+{{.Error}}
+{{- else -}}
+{{with .Info -}}
+{{color "label" "Last Delivered:"}} Consumer seq: {{num .Delivered.Consumer}}  Stream seq: {{num .Delivered.Stream}}  Last delivery: {{ago .Delivered.Last}}
+{{color "label" "Ack Floor:"}}    Consumer seq: {{num .AckFloor.Consumer}}  Stream seq: {{num .AckFloor.Stream}}  Last ack: {{ago .AckFloor.Last}}
+{{color "label" "Outstanding Acks:"}} {{.NumAckPending}} of max {{.Config.MaxAckPending}}
+{{color "label" "Redelivered:"}} {{.NumRedelivered}}
+{{color "label" "Unprocessed:"}} {{.NumPending}}
+{{color "label" "Waiting Pulls:"}} {{.NumWaiting}} of max {{.Config.MaxWaiting}}
+{{end -}}
+{{color "label" "Rate:"}} {{printf "%.1f" .Rates.Delivered}}/s delivered, {{printf "%.1f" .Rates.Acked}}/s acked
+{{- with .Throughput}}
+{{color "accent" "─── Throughput ───"}}
+{{color "accent" "Duration:"}} {{duration .Duration}}
+{{color "accent" "Delivered:"}} {{num .DeliveredCount}} msgs ({{printf "%.1f" .DeliveredRate}}/s)
+{{color "accent" "Acked:"}} {{num .AckedCount}} msgs ({{printf "%.1f" .AckedRate}}/s)
+{{- if gt .AckedStats.Samples 0}}
+{{color "accent" "Delivered/s:"}} {{stats .DeliveredStats}}
+{{color "accent" "Acked/s:"}} {{stats .AckedStats}}
+{{color "accent" "Longest stall:"}} delivered {{duration .DeliveredStats.LongestGap}}, acked {{duration .AckedStats.LongestGap}}
+{{- end}}
+{{- end}}
+{{- with .Baseline}}
+{{comparison .}}
+{{- end}}
+{{- end}}`
+
+// cellData is what a cell template renders.
+type cellData struct {
+	Ref        config.ConsumerRef
+	Info       *nats.ConsumerInfo // nil if the consumer failed to fetch
+	Snapshot   monitor.Snapshot
+	Rates      monitor.Rates
+	Error      error
+	Throughput *monitor.ThroughputMeasurement // nil without a measurement
+	Baseline   *cellBaseline                  // nil unless comparing with a baseline
+}
+
+// cellBaseline compares the throughput of a consumer with a baseline.
+type cellBaseline struct {
+	Name string
+	baseline.Comparison
+}
+
+// templateFuncs are the helpers available to cell templates. Colors are
+// theme roles, such as "label", or color names.
+func templateFuncs(theme Theme) template.FuncMap {
+	return template.FuncMap{
+		"num":        templateNum,
+		"rate":       func(v float64) string { return fmt.Sprintf("%.1f/s", v) },
+		"ago":        templateAgo,
+		"duration":   func(d time.Duration) time.Duration { return d.Round(time.Second) },
+		"color":      func(color string, v any) string { return paint(theme.themeColor(color), fmt.Sprint(v)) },
+		"escape":     func(v any) string { return tview.Escape(fmt.Sprint(v)) },
+		"bar":        templateBar,
+		"stats":      FormatRateStats,
+		"comparison": func(b cellBaseline) string { return formatComparison(theme, b.Name, b.Comparison) },
+	}
+}
+
+// parseCellTemplate parses a cell template, or the built-in one if text is
+// empty.
+func parseCellTemplate(name, text string, theme Theme) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = defaultCellTemplate
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs(theme)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("cell template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// templateNum formats an integer with thousand separators.
+func templateNum(v any) (string, error) {
+	switch n := v.(type) {
+	case int:
+		return formatSigned(int64(n)), nil
+	case int64:
+		return formatSigned(n), nil
+	case uint64:
+		return FormatInt(n), nil
+	case uint:
+		return FormatInt(uint64(n)), nil
+	case float64:
+		return formatSigned(int64(n)), nil
+	default:
+		return "", fmt.Errorf("num: unsupported type %T", v)
+	}
+}
+
+func formatSigned(n int64) string {
+	if n < 0 {
+		return "-" + FormatInt(uint64(-n))
+	}
+	return FormatInt(uint64(n))
+}
+
+// templateAgo formats a time, or a pointer to one, relative to now.
+func templateAgo(v any) (string, error) {
+	switch t := v.(type) {
+	case *time.Time:
+		return Ago(t), nil
+	case time.Time:
+		return Ago(&t), nil
+	default:
+		return "", fmt.Errorf("ago: unsupported type %T", v)
+	}
+}
+
+// templateBar draws value as a bar of width cells scaled to maxValue.
+func templateBar(value, maxValue any, width int) (string, error) {
+	v, err := toFloat(value)
+	if err != nil {
+		return "", err
+	}
+	m, err := toFloat(maxValue)
+	if err != nil {
+		return "", err
+	}
+	return formatBar(v, m, width), nil
+}
+
+func toFloat(v any) (float64, error) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case uint:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	default:
+		return 0, fmt.Errorf("bar: unsupported type %T", v)
+	}
+}
@@ -0,0 +1,228 @@
+package web
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/export"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// windowStateJSON is a window with the latest state of its consumers.
+type windowStateJSON struct {
+	Name      string         `json:"name"`
+	Columns   int            `json:"columns"`
+	Summary   summaryJSON    `json:"summary"`
+	Consumers []consumerJSON `json:"consumers"`
+}
+
+// measurementJSON is a throughput measurement of one consumer.
+type measurementJSON struct {
+	Stream   string `json:"stream"`
+	Consumer string `json:"consumer"`
+	throughputJSON
+}
+
+// throughputStatusJSON is the response of the throughput endpoints.
+type throughputStatusJSON struct {
+	Measuring    bool              `json:"measuring"`
+	Deadline     time.Time         `json:"deadline,omitzero"` // When a timed measurement stops itself
+	Measurements []measurementJSON `json:"measurements"`
+}
+
+// errorJSON is the body of an API error response.
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+// registerAPI adds the JSON API routes to mux.
+func (s *Server) registerAPI(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/windows", s.handleAPIWindows)
+	mux.HandleFunc("GET /api/consumers", s.handleAPIConsumers)
+	mux.HandleFunc("GET /api/consumers/{stream}/{consumer}", s.handleAPIConsumerInfo)
+	mux.HandleFunc("GET /api/throughput", s.handleAPIThroughput)
+	mux.HandleFunc("POST /api/throughput/start", s.handleAPIThroughputStart)
+	mux.HandleFunc("POST /api/throughput/stop", s.handleAPIThroughputStop)
+	mux.HandleFunc("POST /api/throughput/clear", s.handleAPIThroughputClear)
+	mux.HandleFunc("GET /api/throughput/export", s.handleAPIThroughputExport)
+	s.registerBaselines(mux)
+}
+
+func (s *Server) handleAPIWindows(w http.ResponseWriter, r *http.Request) {
+	byKey := s.statesByKey()
+	all := s.throughput.All()
+	windows := make([]windowStateJSON, len(s.windows))
+	for i, win := range s.windows {
+		windows[i] = windowStateJSON{
+			Name:      win.Name,
+			Columns:   win.Columns,
+			Summary:   newSummaryJSON(monitor.Summarize(win.Consumers, byKey, all)),
+			Consumers: s.consumersJSON(win.Consumers, byKey),
+		}
+	}
+	writeJSON(w, http.StatusOK, windows)
+}
+
+func (s *Server) handleAPIConsumers(w http.ResponseWriter, r *http.Request) {
+	var refs []config.ConsumerRef
+	for _, win := range s.windows {
+		for _, ref := range win.Consumers {
+			if !slices.Contains(refs, ref) {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, s.consumersJSON(refs, s.statesByKey()))
+}
+
+func (s *Server) handleAPIConsumerInfo(w http.ResponseWriter, r *http.Request) {
+	ref := config.ConsumerRef{Stream: r.PathValue("stream"), Consumer: r.PathValue("consumer")}
+	state, ok := s.statesByKey()[ref.Key()]
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusNotFound, errorJSON{Error: "consumer is not monitored: " + ref.Key()})
+	case state.Error != nil:
+		writeJSON(w, http.StatusBadGateway, errorJSON{Error: state.Error.Error()})
+	case state.Info == nil:
+		writeJSON(w, http.StatusServiceUnavailable, errorJSON{Error: "consumer not polled yet"})
+	default:
+		writeJSON(w, http.StatusOK, state.Info)
+	}
+}
+
+func (s *Server) handleAPIThroughput(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.throughputStatus())
+}
+
+func (s *Server) handleAPIThroughputStart(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	states := s.lastStates
+	s.mu.RUnlock()
+	if states == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorJSON{Error: "no consumer state yet"})
+		return
+	}
+
+	// With ?duration= the measurement stops itself
+	var started bool
+	if d := r.URL.Query().Get("duration"); d != "" {
+		duration, err := time.ParseDuration(d)
+		if err != nil || duration <= 0 {
+			writeJSON(w, http.StatusBadRequest, errorJSON{Error: "invalid duration: " + d})
+			return
+		}
+		started = s.throughput.StartFor(states, duration)
+	} else {
+		started = s.throughput.Start(states)
+	}
+	if !started {
+		writeJSON(w, http.StatusConflict, errorJSON{Error: "a measurement is already running"})
+		return
+	}
+	s.broadcast(false)
+	writeJSON(w, http.StatusOK, s.throughputStatus())
+}
+
+func (s *Server) handleAPIThroughputStop(w http.ResponseWriter, r *http.Request) {
+	if !s.throughput.Stop() {
+		writeJSON(w, http.StatusConflict, errorJSON{Error: "no measurement is running"})
+		return
+	}
+	s.broadcast(false)
+	writeJSON(w, http.StatusOK, s.throughputStatus())
+}
+
+func (s *Server) handleAPIThroughputClear(w http.ResponseWriter, r *http.Request) {
+	s.throughput.Clear()
+	s.broadcast(false)
+	writeJSON(w, http.StatusOK, s.throughputStatus())
+}
+
+func (s *Server) handleAPIThroughputExport(w http.ResponseWriter, r *http.Request) {
+	format := cmp.Or(r.URL.Query().Get("format"), export.FormatCSV)
+	if !slices.Contains(export.Formats, format) {
+		writeJSON(w, http.StatusBadRequest, errorJSON{Error: "unknown format: " + format})
+		return
+	}
+
+	now := time.Now()
+	report := export.Report{Time: now}
+	all := s.throughput.All()
+	for _, win := range s.windows {
+		if ew := export.NewWindow(win, all); len(ew.Consumers) > 0 {
+			report.Windows = append(report.Windows, ew)
+		}
+	}
+
+	name := fmt.Sprintf("throughput-%s.%s", now.Format("20060102-150405"), export.Extension(format))
+	w.Header().Set("Content-Type", export.ContentType(format))
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	_ = export.Write(w, format, report)
+}
+
+// statesByKey returns the latest states keyed by "stream/consumer".
+func (s *Server) statesByKey() map[string]monitor.ConsumerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	byKey := make(map[string]monitor.ConsumerState, len(s.lastStates))
+	for _, state := range s.lastStates {
+		byKey[state.Ref.Key()] = state
+	}
+	return byKey
+}
+
+// consumersJSON returns the views of refs that have been polled.
+func (s *Server) consumersJSON(refs []config.ConsumerRef, byKey map[string]monitor.ConsumerState) []consumerJSON {
+	consumers := make([]consumerJSON, 0, len(refs))
+	for _, ref := range refs {
+		state, ok := byKey[ref.Key()]
+		if !ok {
+			continue
+		}
+		consumers = append(consumers, newConsumerJSON(state, s.throughput.Get(ref.Stream, ref.Consumer)))
+	}
+	return consumers
+}
+
+func (s *Server) throughputStatus() throughputStatusJSON {
+	status := throughputStatusJSON{
+		Measuring:    s.throughput.IsMeasuring(),
+		Deadline:     s.throughput.Deadline(),
+		Measurements: []measurementJSON{},
+	}
+
+	all := s.throughput.All()
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		m := all[key]
+		ref := s.refByKey(key)
+		status.Measurements = append(status.Measurements, measurementJSON{
+			Stream:         ref.Stream,
+			Consumer:       ref.Consumer,
+			throughputJSON: *newThroughputJSON(&m),
+		})
+	}
+	return status
+}
+
+// refByKey finds the configured consumer with the given key.
+func (s *Server) refByKey(key string) config.ConsumerRef {
+	for _, win := range s.windows {
+		for _, ref := range win.Consumers {
+			if ref.Key() == key {
+				return ref
+			}
+		}
+	}
+	return config.ConsumerRef{}
+}
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+)
+
+// baselineJSON describes a saved baseline.
+type baselineJSON struct {
+	Name      string    `json:"name"`
+	SavedAt   time.Time `json:"saved_at"`
+	Duration  float64   `json:"duration_seconds"`
+	Consumers int       `json:"consumers"`
+}
+
+// changeJSON is the change of a rate against a baseline.
+type changeJSON struct {
+	Baseline  float64 `json:"baseline"`
+	Current   float64 `json:"current"`
+	Delta     float64 `json:"delta"`
+	Percent   float64 `json:"percent"`
+	Regressed bool    `json:"regressed"`
+}
+
+// comparisonJSON compares a consumer, or a window total, with a baseline.
+type comparisonJSON struct {
+	Stream    string     `json:"stream,omitempty"`
+	Consumer  string     `json:"consumer,omitempty"`
+	Delivered changeJSON `json:"delivered_rate"`
+	Acked     changeJSON `json:"acked_rate"`
+}
+
+// windowComparisonJSON compares the consumers of a window with a baseline.
+type windowComparisonJSON struct {
+	Name      string           `json:"name"`
+	Consumers []comparisonJSON `json:"consumers"`
+	Total     comparisonJSON   `json:"total"`
+}
+
+// compareJSON is the response of the compare endpoint.
+type compareJSON struct {
+	Baseline  string                 `json:"baseline"`
+	Threshold float64                `json:"regression_threshold"`
+	Windows   []windowComparisonJSON `json:"windows"`
+}
+
+// SetBaselines sets the store the baseline endpoints use, and the rate drop
+// in percent flagged as a regression. Without a store they answer 404.
+func (s *Server) SetBaselines(store *baseline.Store, threshold float64) {
+	s.baselines = store
+	s.threshold = threshold
+}
+
+func (s *Server) registerBaselines(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/baselines", s.handleAPIBaselines)
+	mux.HandleFunc("POST /api/baselines/{name}", s.handleAPIBaselineSave)
+	mux.HandleFunc("GET /api/baselines/{name}/compare", s.handleAPIBaselineCompare)
+}
+
+func (s *Server) handleAPIBaselines(w http.ResponseWriter, r *http.Request) {
+	if s.baselines == nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{Error: "baselines are not enabled"})
+		return
+	}
+	list := []baselineJSON{}
+	for _, name := range s.baselines.Names() {
+		b, _ := s.baselines.Get(name)
+		list = append(list, baselineJSON{Name: name, SavedAt: b.SavedAt, Duration: b.Duration, Consumers: len(b.Consumers)})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleAPIBaselineSave(w http.ResponseWriter, r *http.Request) {
+	if s.baselines == nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{Error: "baselines are not enabled"})
+		return
+	}
+	if s.throughput.IsMeasuring() {
+		writeJSON(w, http.StatusConflict, errorJSON{Error: "a measurement is still running"})
+		return
+	}
+	b, err := s.baselines.Save(r.PathValue("name"), s.throughput.All())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorJSON{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, baselineJSON{Name: b.Name, SavedAt: b.SavedAt, Duration: b.Duration, Consumers: len(b.Consumers)})
+}
+
+func (s *Server) handleAPIBaselineCompare(w http.ResponseWriter, r *http.Request) {
+	if s.baselines == nil {
+		writeJSON(w, http.StatusNotFound, errorJSON{Error: "baselines are not enabled"})
+		return
+	}
+	name := r.PathValue("name")
+	b, ok := s.baselines.Get(name)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorJSON{Error: "no baseline named " + name})
+		return
+	}
+
+	resp := compareJSON{Baseline: name, Threshold: s.threshold, Windows: []windowComparisonJSON{}}
+	all := s.throughput.All()
+	for _, win := range s.windows {
+		wc := b.CompareWindow(win, all, s.threshold)
+		if len(wc.Consumers) == 0 {
+			continue
+		}
+		wj := windowComparisonJSON{Name: win.Name, Total: newComparisonJSON(wc.Total)}
+		for _, c := range wc.Consumers {
+			wj.Consumers = append(wj.Consumers, newComparisonJSON(c))
+		}
+		resp.Windows = append(resp.Windows, wj)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func newComparisonJSON(c baseline.Comparison) comparisonJSON {
+	return comparisonJSON{
+		Stream:    c.Ref.Stream,
+		Consumer:  c.Ref.Consumer,
+		Delivered: changeJSON(c.Delivered),
+		Acked:     changeJSON(c.Acked),
+	}
+}
@@ -0,0 +1,205 @@
+// Package web serves the consumer dashboard to browsers.
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/baseline"
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// keepAliveInterval is how often idle event streams get a comment line so
+// proxies don't close them.
+const keepAliveInterval = 15 * time.Second
+
+//go:embed static
+var static embed.FS
+
+// Server renders the configured windows as an HTML grid and pushes consumer
+// state to browsers with Server-Sent Events. All browsers share the poller
+// subscription and throughput tracker of the server.
+type Server struct {
+	windows    []config.WindowConfig
+	throughput *monitor.ThroughputTracker
+	baselines  *baseline.Store
+	threshold  float64 // Regression threshold in percent
+
+	mu         sync.RWMutex
+	lastStates []monitor.ConsumerState
+	lastEvent  []byte
+	clients    map[chan []byte]struct{}
+}
+
+// NewServer creates a dashboard server for the given windows.
+func NewServer(windows []config.WindowConfig) *Server {
+	return &Server{
+		windows:    windows,
+		throughput: monitor.NewThroughputTracker(),
+		clients:    make(map[chan []byte]struct{}),
+	}
+}
+
+// Run consumes poll updates and broadcasts them until the context is
+// cancelled or the subscription is closed.
+func (s *Server) Run(ctx context.Context, sub *monitor.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			s.throughput.Update(update.States)
+			s.mu.Lock()
+			s.lastStates = update.States
+			s.mu.Unlock()
+			s.broadcast(true)
+		}
+	}
+}
+
+// Handler returns the HTTP handler of the dashboard.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(mustSub(static, "static")))
+	mux.HandleFunc("GET /layout", s.handleLayout)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	s.registerAPI(mux)
+	return mux
+}
+
+// ListenAndServe serves the dashboard on addr until the context is cancelled.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *Server) handleLayout(w http.ResponseWriter, r *http.Request) {
+	windows := make([]windowJSON, len(s.windows))
+	for i, win := range s.windows {
+		windows[i] = newWindowJSON(win)
+	}
+	writeJSON(w, http.StatusOK, windows)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	s.clients[ch] = struct{}{}
+	if s.lastEvent != nil {
+		ch <- s.lastEvent
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			if _, err := fmt.Fprintf(w, "event: state\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// broadcast sends the current state to every connected browser. A browser
+// that hasn't read the previous event only gets the newest one. Changes are
+// only highlighted when the state comes from a new poll.
+func (s *Server) broadcast(fresh bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payload := stateJSON{
+		Time:      time.Now(),
+		Measuring: s.throughput.IsMeasuring(),
+		Consumers: make(map[string]consumerJSON, len(s.lastStates)),
+	}
+	for _, state := range s.lastStates {
+		m := s.throughput.Get(state.Ref.Stream, state.Ref.Consumer)
+		c := newConsumerJSON(state, m)
+		c.Changed = c.Changed && fresh
+		payload.Consumers[state.Ref.Key()] = c
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	s.lastEvent = data
+
+	for ch := range s.clients {
+		select {
+		case <-ch: // drop the stale event
+		default:
+		}
+		ch <- data
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func mustSub(fsys embed.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,219 @@
+"use strict";
+
+const flashDuration = 180;
+
+let windows = [];
+let current = 0;
+let latest = null;
+const cells = new Map(); // "stream/consumer" -> element of the current window
+
+function formatInt(n) {
+  return Number(n).toLocaleString("en-US");
+}
+
+function ago(ts) {
+  if (!ts) return "never";
+  const secs = Math.round((Date.now() - new Date(ts).getTime()) / 1000);
+  if (secs < 60) return `${secs}s ago`;
+  const mins = Math.floor(secs / 60);
+  if (mins < 60) return `${mins}m${secs % 60}s ago`;
+  return `${Math.floor(mins / 60)}h${mins % 60}m ago`;
+}
+
+function escapeHTML(s) {
+  return s.replace(/[&<>"']/g, (c) => `&#${c.charCodeAt(0)};`);
+}
+
+function formatStats(d) {
+  return `min ${d.min.toFixed(1)}  p50 ${d.p50.toFixed(1)}  p95 ${d.p95.toFixed(1)}  max ${d.max.toFixed(1)}  σ ${d.stddev.toFixed(1)}`;
+}
+
+function formatConsumer(c) {
+  if (c.error) {
+    return `<span class="error">ERROR</span>\n${escapeHTML(c.error)}`;
+  }
+  let text =
+    `<span class="label">Last Delivered:</span> Consumer seq: ${formatInt(c.delivered.consumer_seq)}  Stream seq: ${formatInt(c.delivered.stream_seq)}  Last delivery: ${ago(c.delivered.last)}\n` +
+    `<span class="label">Ack Floor:</span>    Consumer seq: ${formatInt(c.ack_floor.consumer_seq)}  Stream seq: ${formatInt(c.ack_floor.stream_seq)}  Last ack: ${ago(c.ack_floor.last)}\n` +
+    `<span class="label">Outstanding Acks:</span> ${c.num_ack_pending} of max ${c.max_ack_pending}\n` +
+    `<span class="label">Redelivered:</span> ${c.num_redelivered}\n` +
+    `<span class="label">Unprocessed:</span> ${c.num_pending}\n` +
+    `<span class="label">Waiting Pulls:</span> ${c.num_waiting} of max ${c.max_waiting}\n` +
+    `<span class="label">Rate:</span> ${c.delivered_rate.toFixed(1)}/s delivered, ${c.acked_rate.toFixed(1)}/s acked`;
+
+  const t = c.throughput;
+  if (t) {
+    text +=
+      `\n<span class="throughput">─── Throughput ───</span>\n` +
+      `<span class="throughput">Duration:</span> ${Math.round(t.duration_seconds)}s\n` +
+      `<span class="throughput">Delivered:</span> ${formatInt(t.delivered)} msgs (${t.delivered_rate.toFixed(1)}/s)\n` +
+      `<span class="throughput">Acked:</span> ${formatInt(t.acked)} msgs (${t.acked_rate.toFixed(1)}/s)`;
+    if (t.delivered_stats && t.acked_stats) {
+      text +=
+        `\n<span class="throughput">Delivered/s:</span> ${formatStats(t.delivered_stats)}\n` +
+        `<span class="throughput">Acked/s:</span> ${formatStats(t.acked_stats)}\n` +
+        `<span class="throughput">Longest stall:</span> delivered ${Math.round(t.delivered_stats.longest_gap_seconds)}s, ` +
+        `acked ${Math.round(t.acked_stats.longest_gap_seconds)}s`;
+    }
+  }
+  return text;
+}
+
+// partitions names the slowest and fastest of [name, acked rate] pairs.
+function partitions(cls, rates) {
+  if (rates.length < 2) return "";
+  rates.sort((a, b) => a[1] - b[1]);
+  const [slow, fast] = [rates[0], rates[rates.length - 1]];
+  return `  <span class="${cls}">Slowest:</span> ${escapeHTML(slow[0])} (${slow[1].toFixed(1)}/s)` +
+    `  <span class="${cls}">Fastest:</span> ${escapeHTML(fast[0])} (${fast[1].toFixed(1)}/s)`;
+}
+
+// formatSummary totals the consumers of a window like the terminal UI.
+function formatSummary(win, state) {
+  let n = 0, errors = 0, pending = 0, ackPending = 0, delivered = 0, acked = 0;
+  const measured = { n: 0, delivered: 0, acked: 0, deliveredRate: 0, ackedRate: 0 };
+  const rates = [], measuredRates = [];
+  const seen = new Set();
+  for (const ref of win.consumers) {
+    const key = `${ref.stream}/${ref.consumer}`;
+    const c = state.consumers[key];
+    if (!c || seen.has(key)) continue;
+    seen.add(key);
+    n++;
+    if (c.throughput) {
+      const t = c.throughput;
+      measured.n++;
+      measured.delivered += t.delivered;
+      measured.acked += t.acked;
+      measured.deliveredRate += t.delivered_rate;
+      measured.ackedRate += t.acked_rate;
+      measuredRates.push([ref.consumer, t.acked_rate]);
+    }
+    if (c.error) {
+      errors++;
+      continue;
+    }
+    pending += c.num_pending;
+    ackPending += c.num_ack_pending;
+    delivered += c.delivered_rate;
+    acked += c.acked_rate;
+    rates.push([ref.consumer, c.acked_rate]);
+  }
+
+  let text =
+    `<span class="label">Σ ${n} consumers:</span> ${delivered.toFixed(1)}/s delivered, ${acked.toFixed(1)}/s acked` +
+    `  <span class="label">Unprocessed:</span> ${formatInt(pending)}  <span class="label">Outstanding Acks:</span> ${formatInt(ackPending)}` +
+    partitions("label", rates);
+  if (errors > 0) text += `  <span class="error">${errors} failing</span>`;
+  if (measured.n > 0) {
+    text +=
+      `\n<span class="throughput">Σ Throughput:</span> ${formatInt(measured.delivered)} msgs (${measured.deliveredRate.toFixed(1)}/s) delivered, ` +
+      `${formatInt(measured.acked)} msgs (${measured.ackedRate.toFixed(1)}/s) acked` +
+      partitions("throughput", measuredRates);
+  }
+  return text;
+}
+
+function renderTabs() {
+  const nav = document.getElementById("tabs");
+  nav.innerHTML = "";
+  windows.forEach((w, i) => {
+    const b = document.createElement("button");
+    b.textContent = w.name;
+    b.className = i === current ? "active" : "";
+    b.onclick = () => selectWindow(i);
+    nav.appendChild(b);
+  });
+}
+
+function renderGrid() {
+  const grid = document.getElementById("grid");
+  const win = windows[current];
+  grid.innerHTML = "";
+  grid.style.gridTemplateColumns = `repeat(${win.columns}, minmax(0, 1fr))`;
+  cells.clear();
+
+  for (const ref of win.consumers) {
+    const key = `${ref.stream}/${ref.consumer}`;
+    const cell = document.createElement("section");
+    cell.className = "cell";
+    cell.innerHTML = `<h2 title="${escapeHTML(key)}">${escapeHTML(ref.consumer)}</h2><div class="body"></div>`;
+    grid.appendChild(cell);
+    cells.set(key, cell);
+  }
+  if (latest) render(latest, false);
+}
+
+function selectWindow(i) {
+  current = i;
+  location.hash = encodeURIComponent(windows[i].name);
+  renderTabs();
+  renderGrid();
+}
+
+function flash(cell) {
+  cell.classList.add("flash");
+  setTimeout(() => cell.classList.remove("flash"), flashDuration);
+}
+
+function render(state, highlight) {
+  for (const [key, cell] of cells) {
+    const c = state.consumers[key];
+    if (!c) continue;
+    cell.querySelector(".body").innerHTML = formatConsumer(c);
+    if (highlight && c.changed && !c.error) flash(cell);
+  }
+
+  document.getElementById("summary").innerHTML = formatSummary(windows[current], state);
+
+  const status = document.getElementById("measure-status");
+  const toggle = document.getElementById("toggle");
+  const hasResults = Object.values(state.consumers).some((c) => c.throughput);
+  if (state.measuring) {
+    status.textContent = "▶ Measuring…";
+    status.className = "running";
+    toggle.textContent = "Stop throughput";
+  } else {
+    status.textContent = hasResults ? "■ Done" : "";
+    status.className = "";
+    toggle.textContent = hasResults ? "Restart throughput" : "Start throughput";
+  }
+
+  document.getElementById("status").textContent =
+    `${windows[current].name} (${current + 1}/${windows.length}) · updated ${new Date(state.time).toLocaleTimeString()}`;
+}
+
+async function post(path) {
+  const resp = await fetch(path, { method: "POST" });
+  if (!resp.ok) {
+    const body = await resp.json().catch(() => ({ error: resp.statusText }));
+    document.getElementById("status").textContent = body.error;
+  }
+}
+
+async function start() {
+  windows = await (await fetch("layout")).json();
+  const fromHash = windows.findIndex((w) => encodeURIComponent(w.name) === location.hash.slice(1));
+  current = fromHash >= 0 ? fromHash : 0;
+  renderTabs();
+  renderGrid();
+
+  document.getElementById("toggle").onclick = () =>
+    post(latest && latest.measuring ? "api/throughput/stop" : "api/throughput/start");
+  document.getElementById("clear").onclick = () => post("api/throughput/clear");
+  document.getElementById("export").onclick = () => {
+    const format = document.getElementById("export-format").value;
+    window.location.href = "api/throughput/export?format=" + format;
+  };
+
+  const events = new EventSource("events");
+  events.addEventListener("state", (e) => {
+    latest = JSON.parse(e.data);
+    render(latest, true);
+  });
+  events.onerror = () => {
+    document.getElementById("status").textContent = "Disconnected, retrying…";
+  };
+}
+
+start();
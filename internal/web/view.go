@@ -0,0 +1,176 @@
+package web
+
+import (
+	"time"
+
+	"github.com/jrlangford/nats-consumer-monitor/internal/config"
+	"github.com/jrlangford/nats-consumer-monitor/internal/export"
+	"github.com/jrlangford/nats-consumer-monitor/internal/monitor"
+)
+
+// sequenceJSON is a delivered or ack floor position.
+type sequenceJSON struct {
+	Consumer uint64     `json:"consumer_seq"`
+	Stream   uint64     `json:"stream_seq"`
+	Last     *time.Time `json:"last,omitempty"`
+}
+
+// throughputJSON is a throughput measurement.
+type throughputJSON struct {
+	Running       bool      `json:"running"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time,omitzero"`
+	Duration      float64   `json:"duration_seconds"`
+	Delivered     uint64    `json:"delivered"`
+	Acked         uint64    `json:"acked"`
+	DeliveredRate float64   `json:"delivered_rate"`
+	AckedRate     float64   `json:"acked_rate"`
+
+	DeliveredStats *export.Distribution `json:"delivered_stats,omitempty"`
+	AckedStats     *export.Distribution `json:"acked_stats,omitempty"`
+}
+
+// consumerJSON is the browser view of a consumer state.
+type consumerJSON struct {
+	Stream         string          `json:"stream"`
+	Consumer       string          `json:"consumer"`
+	Error          string          `json:"error,omitempty"`
+	Changed        bool            `json:"changed"`
+	PolledAt       time.Time       `json:"polled_at"`
+	Delivered      sequenceJSON    `json:"delivered"`
+	AckFloor       sequenceJSON    `json:"ack_floor"`
+	NumAckPending  int             `json:"num_ack_pending"`
+	MaxAckPending  int             `json:"max_ack_pending"`
+	NumRedelivered int             `json:"num_redelivered"`
+	NumPending     uint64          `json:"num_pending"`
+	NumWaiting     int             `json:"num_waiting"`
+	MaxWaiting     int             `json:"max_waiting"`
+	DeliveredRate  float64         `json:"delivered_rate"` // Continuous rate
+	AckedRate      float64         `json:"acked_rate"`
+	Throughput     *throughputJSON `json:"throughput,omitempty"`
+}
+
+// partitionJSON is the slowest or fastest consumer of a window.
+type partitionJSON struct {
+	Stream    string  `json:"stream"`
+	Consumer  string  `json:"consumer"`
+	AckedRate float64 `json:"acked_rate"`
+}
+
+// rateTotalsJSON is the sum of the rates of a window.
+type rateTotalsJSON struct {
+	DeliveredRate float64        `json:"delivered_rate"`
+	AckedRate     float64        `json:"acked_rate"`
+	Slowest       *partitionJSON `json:"slowest,omitempty"`
+	Fastest       *partitionJSON `json:"fastest,omitempty"`
+}
+
+// summaryJSON aggregates the consumers of a window.
+type summaryJSON struct {
+	Consumers  int             `json:"consumers"`
+	Errors     int             `json:"errors"`
+	Pending    uint64          `json:"num_pending"`
+	AckPending int             `json:"num_ack_pending"`
+	Rates      rateTotalsJSON  `json:"rates"`
+	Throughput *rateTotalsJSON `json:"throughput,omitempty"` // Only with a measurement
+	Delivered  uint64          `json:"delivered,omitempty"`  // Messages delivered during the measurement
+	Acked      uint64          `json:"acked,omitempty"`
+}
+
+// stateJSON is the payload of a state event.
+type stateJSON struct {
+	Time      time.Time               `json:"time"`
+	Measuring bool                    `json:"measuring"`
+	Consumers map[string]consumerJSON `json:"consumers"` // keyed by "stream/consumer"
+}
+
+// windowJSON describes the layout of a window.
+type windowJSON struct {
+	Name      string               `json:"name"`
+	Columns   int                  `json:"columns"`
+	Consumers []config.ConsumerRef `json:"consumers"`
+}
+
+func newConsumerJSON(state monitor.ConsumerState, m *monitor.ThroughputMeasurement) consumerJSON {
+	c := consumerJSON{
+		Stream:   state.Ref.Stream,
+		Consumer: state.Ref.Consumer,
+		Changed:  state.Changed,
+		PolledAt: state.PolledAt,
+	}
+	if state.Error != nil {
+		c.Error = state.Error.Error()
+		return c
+	}
+
+	if ci := state.Info; ci != nil {
+		c.Delivered = sequenceJSON{Consumer: ci.Delivered.Consumer, Stream: ci.Delivered.Stream, Last: ci.Delivered.Last}
+		c.AckFloor = sequenceJSON{Consumer: ci.AckFloor.Consumer, Stream: ci.AckFloor.Stream, Last: ci.AckFloor.Last}
+		c.NumAckPending = ci.NumAckPending
+		c.MaxAckPending = ci.Config.MaxAckPending
+		c.NumRedelivered = ci.NumRedelivered
+		c.NumPending = ci.NumPending
+		c.NumWaiting = ci.NumWaiting
+		c.MaxWaiting = ci.Config.MaxWaiting
+	}
+	c.DeliveredRate = state.Rates.Delivered
+	c.AckedRate = state.Rates.Acked
+
+	if m != nil {
+		c.Throughput = newThroughputJSON(m)
+	}
+	return c
+}
+
+func newThroughputJSON(m *monitor.ThroughputMeasurement) *throughputJSON {
+	return &throughputJSON{
+		Running:       m.EndTime.IsZero(),
+		StartTime:     m.StartTime,
+		EndTime:       m.EndTime,
+		Duration:      m.Duration().Seconds(),
+		Delivered:     m.DeliveredCount(),
+		Acked:         m.AckedCount(),
+		DeliveredRate: m.DeliveredRate(),
+		AckedRate:     m.AckedRate(),
+
+		DeliveredStats: export.NewDistribution(m.DeliveredStats()),
+		AckedStats:     export.NewDistribution(m.AckedStats()),
+	}
+}
+
+func newSummaryJSON(s monitor.WindowSummary) summaryJSON {
+	sum := summaryJSON{
+		Consumers:  s.Consumers,
+		Errors:     s.Errors,
+		Pending:    s.Pending,
+		AckPending: s.AckPending,
+		Rates:      newRateTotalsJSON(s.Rates),
+	}
+	if s.Measured > 0 {
+		t := newRateTotalsJSON(s.Throughput)
+		sum.Throughput = &t
+		sum.Delivered = s.Delivered
+		sum.Acked = s.Acked
+	}
+	return sum
+}
+
+func newRateTotalsJSON(t monitor.RateTotals) rateTotalsJSON {
+	return rateTotalsJSON{
+		DeliveredRate: t.Delivered,
+		AckedRate:     t.Acked,
+		Slowest:       newPartitionJSON(t.Slowest),
+		Fastest:       newPartitionJSON(t.Fastest),
+	}
+}
+
+func newPartitionJSON(p *monitor.Partition) *partitionJSON {
+	if p == nil {
+		return nil
+	}
+	return &partitionJSON{Stream: p.Ref.Stream, Consumer: p.Ref.Consumer, AckedRate: p.AckedRate}
+}
+
+func newWindowJSON(w config.WindowConfig) windowJSON {
+	return windowJSON{Name: w.Name, Columns: w.Columns, Consumers: w.Consumers}
+}